@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/bruxaodev/go-mp-server/pkg/server"
@@ -21,6 +22,10 @@ func NewClient(conn *server.Conn) *Client {
 	}
 }
 
+func (c *Client) GetRoom() string {
+	return c.Room
+}
+
 type Message struct {
 	Type string          `json:"type"`
 	Data json.RawMessage `json:"data"`
@@ -34,35 +39,31 @@ func NewMessage(msg *server.Message) *Message {
 }
 
 func main() {
-	s, err := server.New("localhost:8888", 60, NewClient, NewMessage)
+	s, err := server.New("localhost:8888", NewClient, NewMessage, server.WithTickRate(60))
 	if err != nil {
 		panic(err)
 	}
 
 	s.OnConn = func(c *Client) {
-		println("Client connected:", c.GetID())
+		println("Client connected:", c.GetID(), "from", c.GetRemoteAddr().String())
 	}
 
-	s.OnDisc = func(c *Client, err error) {
-		println("Client disconnected:", c.GetID(), "error:", err.Error())
-	}
-
-	s.OnMsg = func(c *Client, msg *Message) {
-		println("Received message from", c.GetID(), "type:", msg.Type)
-		str, err := c.GetConn().OpenStream()
-		if err != nil {
-			println("Error opening stream:", err.Error())
+	s.OnDisc = func(c *Client, info server.DisconnectInfo) {
+		if info.Reason == server.ReasonError {
+			println("Client disconnected:", c.GetID(), "error:", info.Err.Error())
 			return
 		}
+		println("Client disconnected:", c.GetID(), "reason:", info.Reason.String())
+	}
 
-		d, _ := json.Marshal(msg)
-		_, err = str.Write(d)
+	s.OnMsg = func(_ context.Context, c *Client, msg *Message) {
+		println("Received message from", c.GetID(), "type:", msg.Type)
+		err := c.Send(&server.Message{Type: msg.Type, Data: msg.Data})
 		if err != nil {
-			println("Error writing to stream:", err.Error())
+			println("Error sending message:", err.Error())
 		}
-		str.Close()
 	}
-	s.Start()
-	defer s.Stop()
-	select {}
+	if err := s.RunUntilSignal(); err != nil {
+		panic(err)
+	}
 }
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/bruxaodev/go-mp-server/pkg/server"
 )
@@ -48,47 +50,35 @@ func MessageFactory(msg *server.Message) *Message {
 }
 
 func main() {
-	s, err := server.New("localhost:8888", 60, ClientFactory, MessageFactory)
+	s, err := server.New("localhost:8888", ClientFactory, MessageFactory, server.WithTickRate(60))
 	if err != nil {
 		panic(err)
 	}
 	s.OnConn = func(c *Player) {
-		println("Client connected:", c.GetID())
+		println("Client connected:", c.GetID(), "from", c.GetRemoteAddr().String())
 	}
-	s.OnDisc = func(c *Player, err error) {
-		println("Client disconnected:", c.GetID(), "error:", err.Error())
+	s.OnDisc = func(c *Player, info server.DisconnectInfo) {
+		if info.Reason == server.ReasonError {
+			println("Client disconnected:", c.GetID(), "error:", info.Err.Error())
+			return
+		}
+		println("Client disconnected:", info.Client.ID, "reason:", info.Reason.String(), "after:", info.Duration.String())
 	}
-	s.OnMsg = func(c *Player, msg *Message) {
-		switch msg.Type {
-		case MessageTypeMove:
-
-		case MessageTypeAttack:
-
-		case MessageTypeChat:
-
-		case MessageTypePing:
-			str, err := c.GetConn().OpenStream()
-			if err != nil {
-				println("Error opening stream:", err.Error())
-				return
-			}
-			d, _ := json.Marshal(msg)
-			_, err = str.Write(d)
-			if err != nil {
-				println("Error writing to stream:", err.Error())
-			}
-			str.Close()
-
-		default:
-			println("Unknown message type:", msg.Type)
+	s.Handle(string(MessageTypePing), func(_ context.Context, c *Player, msg *Message) {
+		err := c.Send(&server.Message{Type: string(msg.Type), Data: msg.Data})
+		if err != nil {
+			println("Error sending message:", err.Error())
 		}
+	})
+	s.OnUnhandled = func(_ context.Context, c *Player, msg *Message) {
+		println("Unknown message type:", msg.Type)
 	}
-	s.TickFn = func(s *server.Server[*Player, *Message]) {
+	s.TickFn = func(s *server.Server[*Player, *Message], dt time.Duration) {
 		// Game loop logic here - usar BroadcastDatagram para mensagens frequentes
 		tickData := []byte(`{"type":"tick","data":null}`)
 		s.BroadcastDatagram(tickData)
 	}
-	s.Start()
-	defer s.Stop()
-	select {}
+	if err := s.RunUntilSignal(); err != nil {
+		panic(err)
+	}
 }
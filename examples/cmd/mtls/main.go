@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"time"
+
+	"github.com/bruxaodev/go-mp-server/pkg/server"
+	"github.com/quic-go/quic-go"
+)
+
+// newCA cria uma CA autoassinada para emitir certificados de client
+func newCA() (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"MiniGame Beta CA"}},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+// issueClientCert emite um certificado de client assinado pela CA
+func issueClientCert(ca *x509.Certificate, caKey *ecdsa.PrivateKey, name string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name, Organization: []string{"MiniGame Beta"}},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+func main() {
+	ca, caKey, err := newCA()
+	if err != nil {
+		panic(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	s, err := server.NewDefaultServer("localhost:8889", server.WithClientAuth(pool, true))
+	if err != nil {
+		panic(err)
+	}
+	s.OnConn = func(c *server.Client) {
+		println("Beta tester connected:", c.GetMeta()[server.MetaKeyCertSubject].(string))
+	}
+	s.Start()
+	defer s.Stop()
+
+	cert, err := issueClientCert(ca, caKey, "player-1")
+	if err != nil {
+		panic(err)
+	}
+	conn, err := quic.DialAddr(context.Background(), "localhost:8889", &tls.Config{
+		InsecureSkipVerify: true,
+		Certificates:       []tls.Certificate{cert},
+		NextProtos:         []string{server.DefaultALPN},
+	}, &quic.Config{EnableDatagrams: true})
+	if err != nil {
+		panic(err)
+	}
+	defer conn.CloseWithError(0, "")
+	time.Sleep(500 * time.Millisecond)
+}
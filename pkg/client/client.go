@@ -0,0 +1,524 @@
+// Package client é o SDK em Go para conectar ao servidor: faz o dial QUIC, os
+// handshakes de versão e auth, lê as mensagens com ou sem framing, entrega
+// cada tipo ao seu handler e reconecta sozinho quando a conexão cai.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bruxaodev/go-mp-server/pkg/server"
+	"github.com/quic-go/quic-go"
+)
+
+// Erros retornados pelo Client, para comparação com errors.Is
+var (
+	// ErrNotConnected indica um envio sem conexão ativa (antes do Connect ou reconectando)
+	ErrNotConnected = errors.New("not connected")
+	// ErrClosed indica um Connect depois do Close
+	ErrClosed = errors.New("client closed")
+	// ErrHandshake indica que o servidor recusou ou não respondeu um handshake
+	ErrHandshake = errors.New("handshake failed")
+	// ErrReconnectFailed indica que as tentativas de WithReconnect se esgotaram
+	ErrReconnectFailed = errors.New("reconnect failed")
+)
+
+// Handler recebe as mensagens de um tipo registrado com On
+type Handler func(msg *server.Message)
+
+// Client é uma conexão com o servidor que sobrevive a quedas: enquanto a
+// reconexão está habilitada, uma conexão perdida é refeita com os mesmos
+// handshakes. Registre os handlers antes do Connect.
+type Client struct {
+	// OnUnhandled recebe as mensagens de tipos sem handler registrado
+	OnUnhandled Handler
+	// OnDatagram recebe os datagramas que não são mensagens de um tipo com handler
+	OnDatagram func(data []byte)
+	// OnDisconnect é chamado quando a conexão cai, antes das tentativas de reconexão
+	OnDisconnect func(err error)
+	// OnReconnect é chamado quando uma reconexão completa os handshakes;
+	// resumed indica que o servidor retomou a sessão anterior (WithSession)
+	OnReconnect func(resumed bool)
+
+	addr string
+	opts options
+
+	handlersMu sync.RWMutex
+	handlers   map[string]Handler
+
+	// sendMu protege conn, out, compression e token e serializa as escritas na
+	// stream persistente
+	sendMu sync.Mutex
+	conn   *quic.Conn
+	out    *quic.Stream
+	// compression é o algoritmo negociado com o servidor na conexão atual
+	compression server.Compression
+	// token é o token de sessão da última conexão, reapresentado na reconexão
+	token string
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	startOnce sync.Once
+	wg        sync.WaitGroup
+	done      chan struct{}
+	err       error
+}
+
+// New cria um client para o endereço; a conexão só é aberta pelo Connect
+func New(addr string, opts ...Option) *Client {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{
+		addr:     addr,
+		opts:     o,
+		handlers: make(map[string]Handler),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// On registra o handler das mensagens do tipo. Os handlers de uma mesma stream
+// rodam em ordem, na goroutine que a lê.
+func (c *Client) On(msgType string, h Handler) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	c.handlers[msgType] = h
+}
+
+// Connect abre a primeira conexão e faz os handshakes. Erros aqui não são
+// retentados; depois dele as quedas são reconectadas em segundo plano.
+func (c *Client) Connect(ctx context.Context) error {
+	if c.ctx.Err() != nil {
+		return ErrClosed
+	}
+	conn, sess, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	started := false
+	c.startOnce.Do(func() {
+		started = true
+		c.setConn(conn, sess.Token)
+		c.wg.Add(1)
+		go c.run(conn)
+	})
+	if !started {
+		conn.CloseWithError(0, "")
+		return errors.New("client already connected")
+	}
+	return nil
+}
+
+// Done é fechado quando o client termina: pelo Close, por uma queda sem
+// reconexão ou por uma recusa definitiva do servidor (veja Err)
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err retorna o motivo do fim do client depois do Done (nil após o Close)
+func (c *Client) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Close encerra a conexão e a reconexão e espera as goroutines do client
+func (c *Client) Close() error {
+	c.cancel()
+	c.sendMu.Lock()
+	conn := c.conn
+	c.sendMu.Unlock()
+	var err error
+	if conn != nil {
+		err = conn.CloseWithError(0, "")
+	}
+	c.wg.Wait()
+	c.finish(nil)
+	return err
+}
+
+func (c *Client) finish(err error) {
+	select {
+	case <-c.done:
+	default:
+		c.err = err
+		close(c.done)
+	}
+}
+
+// Send envia a mensagem por stream: numa stream própria ou, com framing, na
+// stream persistente. Retorna ErrNotConnected enquanto não houver conexão.
+func (c *Client) Send(msg *server.Message) error {
+	data, err := c.opts.codec.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.conn == nil {
+		return ErrNotConnected
+	}
+	if !c.opts.framing {
+		str, err := c.conn.OpenStream()
+		if err != nil {
+			return err
+		}
+		if _, err := str.Write(data); err != nil {
+			str.CancelWrite(0)
+			return err
+		}
+		return str.Close()
+	}
+	if c.out == nil {
+		str, err := c.conn.OpenStream()
+		if err != nil {
+			return err
+		}
+		c.out = str
+	}
+	if err := server.WriteFrameCompressed(c.out, data, c.compression, server.DefaultCompressionThreshold); err != nil {
+		// A próxima mensagem abre uma stream nova
+		c.out.CancelWrite(0)
+		c.out = nil
+		return err
+	}
+	return nil
+}
+
+// SendDatagram envia dados brutos num datagrama, entregues ao OnDatagram do servidor
+func (c *Client) SendDatagram(data []byte) error {
+	c.sendMu.Lock()
+	conn := c.conn
+	c.sendMu.Unlock()
+	if conn == nil {
+		return ErrNotConnected
+	}
+	return conn.SendDatagram(data)
+}
+
+// setConn troca a conexão ativa; conn nil marca a queda e mantém o token
+func (c *Client) setConn(conn *quic.Conn, token string) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	c.conn, c.out, c.compression = conn, nil, server.CompressionNone
+	if conn != nil {
+		c.token = token
+		if strings.HasSuffix(conn.ConnectionState().TLS.NegotiatedProtocol, server.GzipALPNSuffix) {
+			c.compression = c.opts.compression
+		}
+	}
+}
+
+func (c *Client) sessionToken() string {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return c.token
+}
+
+// dial abre a conexão QUIC e faz os handshakes configurados
+func (c *Client) dial(ctx context.Context) (*quic.Conn, server.SessionResponse, error) {
+	var sess server.SessionResponse
+	conn, err := quic.DialAddr(ctx, c.addr, c.opts.tlsConf(), c.opts.quicConf())
+	if err != nil {
+		return nil, sess, err
+	}
+	if err := c.handshakes(ctx, conn, &sess); err != nil {
+		conn.CloseWithError(0, "")
+		if rej, ok := server.RejectErrorFrom(context.Cause(conn.Context())); ok {
+			return nil, sess, rej
+		}
+		return nil, sess, err
+	}
+	return conn, sess, nil
+}
+
+func (c *Client) handshakes(ctx context.Context, conn *quic.Conn, sess *server.SessionResponse) error {
+	if c.opts.protocolVersion != 0 {
+		var resp server.VersionResponse
+		req := server.VersionRequest{Version: c.opts.protocolVersion}
+		if err := c.handshake(ctx, conn, server.MessageTypeVersion, server.MessageTypeVersion, req, &resp); err != nil {
+			return err
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("%w: %s", ErrHandshake, resp.Error)
+		}
+	}
+	if c.opts.authToken != "" {
+		req := server.AuthRequest{Token: c.opts.authToken}
+		if err := c.handshake(ctx, conn, server.MessageTypeAuth, server.MessageTypeAuthOK, req, nil); err != nil {
+			return err
+		}
+	}
+	if c.opts.session {
+		req := server.SessionRequest{Token: c.sessionToken()}
+		if err := c.handshake(ctx, conn, server.MessageTypeSession, server.MessageTypeSession, req, sess); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handshake envia a mensagem numa stream nova e lê a resposta do servidor na mesma stream
+func (c *Client) handshake(ctx context.Context, conn *quic.Conn, msgType, replyType string, payload, reply any) error {
+	data, err := c.encode(msgType, payload)
+	if err != nil {
+		return err
+	}
+	str, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		return err
+	}
+	defer str.CancelRead(0)
+	if deadline, ok := ctx.Deadline(); ok {
+		str.SetDeadline(deadline)
+	}
+	if c.opts.framing {
+		err = server.WriteFrame(str, data)
+	} else {
+		_, err = str.Write(data)
+	}
+	if err != nil {
+		return err
+	}
+	str.Close()
+	var resp []byte
+	if c.opts.framing {
+		resp, err = server.ReadFrame(str, c.opts.maxMessageSize)
+	} else {
+		resp, err = io.ReadAll(str)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrHandshake, msgType, err)
+	}
+	var msg server.Message
+	if err := c.opts.codec.Unmarshal(resp, &msg); err != nil || msg.Type != replyType {
+		return fmt.Errorf("%w: expected %q reply", ErrHandshake, replyType)
+	}
+	if reply != nil {
+		return c.opts.codec.Unmarshal(msg.Data, reply)
+	}
+	return nil
+}
+
+func (c *Client) encode(msgType string, payload any) ([]byte, error) {
+	d, err := c.opts.codec.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return c.opts.codec.Marshal(&server.Message{Type: msgType, Data: d})
+}
+
+// run atende a conexão e reconecta enquanto o client não for fechado
+func (c *Client) run(conn *quic.Conn) {
+	defer c.wg.Done()
+	for {
+		c.serve(conn)
+		err := closeErr(context.Cause(conn.Context()))
+		c.setConn(nil, "")
+		if c.ctx.Err() != nil {
+			return
+		}
+		if c.OnDisconnect != nil {
+			c.OnDisconnect(err)
+		}
+		if !c.opts.reconnect || permanent(err) {
+			c.finish(err)
+			return
+		}
+		var sess server.SessionResponse
+		conn, sess, err = c.reconnect()
+		if conn == nil {
+			c.finish(err)
+			return
+		}
+		c.setConn(conn, sess.Token)
+		if c.OnReconnect != nil {
+			c.OnReconnect(sess.Resumed)
+		}
+	}
+}
+
+// reconnect refaz a conexão esperando entre as tentativas, com a espera
+// dobrando a cada falha até o máximo. Retorna nil se o client foi fechado, se
+// as tentativas acabaram ou se o servidor recusou o client de forma definitiva.
+func (c *Client) reconnect() (*quic.Conn, server.SessionResponse, error) {
+	wait := c.opts.reconnectBase
+	for attempt := 1; ; attempt++ {
+		timer := time.NewTimer(jitter(wait))
+		select {
+		case <-c.ctx.Done():
+			timer.Stop()
+			return nil, server.SessionResponse{}, nil
+		case <-timer.C:
+		}
+		conn, sess, err := c.dial(c.ctx)
+		if err == nil {
+			return conn, sess, nil
+		}
+		if c.ctx.Err() != nil {
+			return nil, sess, nil
+		}
+		if permanent(err) {
+			if c.opts.session && c.sessionToken() != "" {
+				// Token recusado (sessão expirada ou segredo trocado): tenta uma sessão nova
+				c.clearToken()
+			} else {
+				return nil, sess, err
+			}
+		}
+		if c.opts.maxAttempts > 0 && attempt >= c.opts.maxAttempts {
+			return nil, sess, fmt.Errorf("%w after %d attempts: %w", ErrReconnectFailed, attempt, err)
+		}
+		log.Printf("reconnect to %s failed: %v\n", c.addr, err)
+		wait = nextWait(wait, c.opts.reconnectMax)
+	}
+}
+
+// nextWait dobra a espera entre as tentativas, sem passar de max
+func nextWait(wait, max time.Duration) time.Duration {
+	if wait >= max/2 {
+		return max
+	}
+	return wait * 2
+}
+
+func (c *Client) clearToken() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	c.token = ""
+}
+
+// jitter sorteia a espera entre metade e o total de d, para que clients
+// derrubados juntos não reconectem todos no mesmo instante
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// permanent indica uma recusa que se repetiria a cada tentativa
+func permanent(err error) bool {
+	rej, ok := err.(*server.RejectError)
+	if !ok {
+		if rej, ok = server.RejectErrorFrom(err); !ok {
+			return false
+		}
+	}
+	switch rej.Code {
+	case server.CloseCodeAuthFailed, server.CloseCodeBanned, server.CloseCodeUnsupportedVersion,
+		server.CloseCodeRejected, server.CloseCodeSessionResumed:
+		return true
+	}
+	return false
+}
+
+// closeErr converte o fechamento pelo servidor em *server.RejectError
+func closeErr(err error) error {
+	if rej, ok := server.RejectErrorFrom(err); ok {
+		return rej
+	}
+	return err
+}
+
+// serve lê as streams e os datagramas da conexão até ela terminar
+func (c *Client) serve(conn *quic.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.datagramLoop(conn)
+	}()
+	for {
+		str, err := conn.AcceptStream(c.ctx)
+		if err != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.readStream(str)
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *Client) readStream(str *quic.Stream) {
+	defer str.CancelRead(0)
+	if !c.opts.framing {
+		data, err := c.readAll(str)
+		if err == nil && len(data) > 0 {
+			c.dispatch(data, nil)
+		}
+		return
+	}
+	for {
+		data, err := server.ReadFrame(str, c.opts.maxMessageSize)
+		if err != nil {
+			return
+		}
+		c.dispatch(data, nil)
+	}
+}
+
+func (c *Client) readAll(r io.Reader) ([]byte, error) {
+	limit := c.opts.maxMessageSize
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > limit {
+		return nil, server.ErrMessageTooLarge
+	}
+	return data, nil
+}
+
+func (c *Client) datagramLoop(conn *quic.Conn) {
+	for {
+		data, err := conn.ReceiveDatagram(c.ctx)
+		if err != nil {
+			return
+		}
+		c.dispatch(data, c.OnDatagram)
+	}
+}
+
+// dispatch entrega a mensagem ao handler do tipo. Dados que não decodificam
+// como mensagem, ou de tipo sem handler, vão para raw quando ele existe.
+func (c *Client) dispatch(data []byte, raw func([]byte)) {
+	var msg server.Message
+	if err := c.opts.codec.Unmarshal(data, &msg); err != nil || msg.Type == "" {
+		if raw != nil {
+			raw(data)
+		}
+		return
+	}
+	c.handlersMu.RLock()
+	h := c.handlers[msg.Type]
+	c.handlersMu.RUnlock()
+	switch {
+	case h != nil:
+		h(&msg)
+	case raw != nil:
+		raw(data)
+	case c.OnUnhandled != nil:
+		c.OnUnhandled(&msg)
+	}
+}
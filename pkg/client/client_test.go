@@ -0,0 +1,293 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bruxaodev/go-mp-server/pkg/server"
+	"github.com/quic-go/quic-go"
+)
+
+type testServer = server.Server[*server.Client, *server.Message]
+
+// newTestServer cria um servidor em loopback; configure os callbacks antes do startServer
+func newTestServer(t *testing.T, opts ...server.Option) *testServer {
+	t.Helper()
+	s, err := server.New("127.0.0.1:0", server.NewClient, server.NewMessage, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// startServer inicia o servidor e retorna o endereço para o client
+func startServer(t *testing.T, s *testServer) string {
+	t.Helper()
+	s.Start()
+	t.Cleanup(s.Stop)
+	return s.Addr().String()
+}
+
+// connect cria e conecta um client ao endereço
+func connect(t *testing.T, ctx context.Context, addr string, opts ...Option) *Client {
+	t.Helper()
+	c := New(addr, append([]Option{WithInsecureSkipVerify()}, opts...)...)
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// receive espera um valor do canal ou falha no fim do ctx
+func receive[V any](t *testing.T, ctx context.Context, ch <-chan V, what string) V {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-ctx.Done():
+		t.Fatalf("%s: %v", what, ctx.Err())
+		var zero V
+		return zero
+	}
+}
+
+func TestClientFramedSendAndDispatch(t *testing.T) {
+	s := newTestServer(t, server.WithFraming(true))
+	s.Handle("ping", func(_ context.Context, c *server.Client, msg *server.Message) {
+		c.Send(&server.Message{Type: "pong", Data: msg.Data})
+		c.Send(&server.Message{Type: "news", Data: []byte(`"extra"`)})
+	})
+	addr := startServer(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := New(addr, WithInsecureSkipVerify(), WithFraming(true))
+	pongs := make(chan *server.Message, 4)
+	unhandled := make(chan *server.Message, 4)
+	c.On("pong", func(msg *server.Message) { pongs <- msg })
+	c.OnUnhandled = func(msg *server.Message) { unhandled <- msg }
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	for i, data := range []string{`1`, `2`} {
+		if err := c.Send(&server.Message{Type: "ping", Data: []byte(data)}); err != nil {
+			t.Fatal(err)
+		}
+		pong := receive(t, ctx, pongs, "pong")
+		if string(pong.Data) != data {
+			t.Fatalf("pong %d data = %s, want %s", i, pong.Data, data)
+		}
+		if msg := receive(t, ctx, unhandled, "unhandled"); msg.Type != "news" {
+			t.Fatalf("OnUnhandled got %q, want news", msg.Type)
+		}
+	}
+}
+
+func TestClientUnframedSend(t *testing.T) {
+	s := newTestServer(t)
+	msgs := make(chan *server.Message, 1)
+	s.OnMsg = func(_ context.Context, _ *server.Client, msg *server.Message) { msgs <- msg }
+	addr := startServer(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := connect(t, ctx, addr)
+	if err := c.Send(&server.Message{Type: "move", Data: []byte(`{"x":1}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, ctx, msgs, "OnMsg"); msg.Type != "move" || string(msg.Data) != `{"x":1}` {
+		t.Fatalf("server got %s %s", msg.Type, msg.Data)
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	s := newTestServer(t)
+	discs := make(chan server.DisconnectInfo, 1)
+	s.OnDisc = func(_ *server.Client, info server.DisconnectInfo) { discs <- info }
+	addr := startServer(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := connect(t, ctx, addr)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Fatal("Done not closed after Close")
+	}
+	if err := c.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil after Close", err)
+	}
+	if err := c.Send(&server.Message{Type: "move"}); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("Send after Close: err = %v, want ErrNotConnected", err)
+	}
+	if err := c.Connect(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Connect after Close: err = %v, want ErrClosed", err)
+	}
+	if info := receive(t, ctx, discs, "OnDisc"); info.Reason != server.ReasonClientClosed {
+		t.Fatalf("disconnect reason = %v, want ReasonClientClosed", info.Reason)
+	}
+}
+
+func TestBackoffIsBounded(t *testing.T) {
+	const base, max = 10 * time.Millisecond, 80 * time.Millisecond
+	wait := base
+	for i := 0; i < 20; i++ {
+		if wait > max {
+			t.Fatalf("attempt %d: wait %v exceeds max %v", i, wait, max)
+		}
+		for j := 0; j < 100; j++ {
+			if d := jitter(wait); d < wait/2 || d > wait {
+				t.Fatalf("jitter(%v) = %v, want within [%v, %v]", wait, d, wait/2, wait)
+			}
+		}
+		wait = nextWait(wait, max)
+	}
+	if wait != max {
+		t.Fatalf("wait = %v after many attempts, want %v", wait, max)
+	}
+	if got := nextWait(time.Duration(1<<62), time.Duration(1<<62)+1); got != time.Duration(1<<62)+1 {
+		t.Fatalf("nextWait overflowed: %v", got)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{&server.RejectError{Code: server.CloseCodeBanned}, true},
+		{&server.RejectError{Code: server.CloseCodeAuthFailed}, true},
+		{&server.RejectError{Code: server.CloseCodeUnsupportedVersion}, true},
+		{&server.RejectError{Code: server.CloseCodeRejected}, true},
+		{&server.RejectError{Code: server.CloseCodeSessionResumed}, true},
+		{&server.RejectError{Code: server.CloseCodeServerFull}, false},
+		{&server.RejectError{Code: server.CloseCodeServerShutdown}, false},
+		{&quic.ApplicationError{Remote: true, ErrorCode: server.CloseCodeBanned}, true},
+		{&quic.ApplicationError{Remote: true, ErrorCode: server.CloseCodeRateLimited}, false},
+		{errors.New("network down"), false},
+	} {
+		if got := permanent(tt.err); got != tt.want {
+			t.Errorf("permanent(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestClientReconnectResumesSession(t *testing.T) {
+	s := newTestServer(t, server.WithReconnectWindow(time.Minute))
+	conns := make(chan *server.Client, 1)
+	resumedOnServer := make(chan *server.Client, 1)
+	s.OnConn = func(c *server.Client) { conns <- c }
+	s.OnReconnect = func(c *server.Client) { resumedOnServer <- c }
+	addr := startServer(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := New(addr, WithInsecureSkipVerify(), WithSession(), WithReconnect(0, 10*time.Millisecond, 50*time.Millisecond))
+	reconnected := make(chan bool, 1)
+	c.OnReconnect = func(resumed bool) { reconnected <- resumed }
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	first := c.sessionToken()
+	if first == "" {
+		t.Fatal("no session token after Connect")
+	}
+
+	// O servidor derruba a conexão com um código que não é uma recusa definitiva
+	sc := receive(t, ctx, conns, "OnConn")
+	sc.Close(0x200, "dropped")
+
+	if !receive(t, ctx, reconnected, "OnReconnect") {
+		t.Fatal("reconnect did not resume the session")
+	}
+	if got := receive(t, ctx, resumedOnServer, "server OnReconnect"); got != sc {
+		t.Fatal("server resumed a different client")
+	}
+	if next := c.sessionToken(); next == "" || next == first {
+		t.Fatalf("session token after resume = %q, want a new token", next)
+	}
+}
+
+func TestClientStopsOnPermanentClose(t *testing.T) {
+	for _, code := range []quic.ApplicationErrorCode{server.CloseCodeBanned, server.CloseCodeAuthFailed, server.CloseCodeUnsupportedVersion} {
+		t.Run(fmt.Sprintf("0x%x", uint64(code)), func(t *testing.T) {
+			s := newTestServer(t)
+			conns := make(chan *server.Client, 2)
+			s.OnConn = func(c *server.Client) { conns <- c }
+			addr := startServer(t, s)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			c := connect(t, ctx, addr, WithReconnect(0, time.Millisecond, time.Millisecond))
+			receive(t, ctx, conns, "OnConn").Close(uint64(code), "go away")
+
+			receive(t, ctx, c.Done(), "client Done")
+			var rej *server.RejectError
+			if !errors.As(c.Err(), &rej) || rej.Code != code {
+				t.Fatalf("Err() = %v, want a RejectError with code 0x%x", c.Err(), uint64(code))
+			}
+			if n := len(conns); n != 0 {
+				t.Fatalf("client reconnected %d times after a permanent close", n)
+			}
+		})
+	}
+}
+
+func TestClientStopsWhenReconnectIsRejected(t *testing.T) {
+	s := newTestServer(t)
+	var accepts atomic.Int32
+	s.OnAccept = func(*server.Client) error {
+		if accepts.Add(1) > 1 {
+			return &server.RejectError{Code: server.CloseCodeBanned, Reason: "banned"}
+		}
+		return nil
+	}
+	conns := make(chan *server.Client, 1)
+	s.OnConn = func(c *server.Client) { conns <- c }
+	addr := startServer(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := connect(t, ctx, addr, WithReconnect(0, time.Millisecond, time.Millisecond))
+	receive(t, ctx, conns, "OnConn").Close(0x200, "dropped")
+
+	receive(t, ctx, c.Done(), "client Done")
+	if !errors.Is(c.Err(), server.ErrBanned) {
+		t.Fatalf("Err() = %v, want ErrBanned", c.Err())
+	}
+	if n := accepts.Load(); n != 2 {
+		t.Fatalf("OnAccept called %d times, want 2 (no retry after the ban)", n)
+	}
+}
+
+func TestClientReconnectGivesUp(t *testing.T) {
+	s := newTestServer(t, server.WithMaxConnections(1), server.WithReconnectWindow(time.Minute))
+	conns := make(chan *server.Client, 2)
+	s.OnConn = func(c *server.Client) { conns <- c }
+	addr := startServer(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := connect(t, ctx, addr, WithSession(), WithReconnect(3, 200*time.Millisecond, 200*time.Millisecond))
+	receive(t, ctx, conns, "OnConn").Close(0x200, "dropped")
+
+	// Outro client ocupa a única vaga antes da primeira tentativa de reconexão
+	connect(t, ctx, addr, WithSession(), WithoutReconnect())
+	receive(t, ctx, conns, "second OnConn")
+
+	receive(t, ctx, c.Done(), "client Done")
+	if !errors.Is(c.Err(), ErrReconnectFailed) {
+		t.Fatalf("Err() = %v, want ErrReconnectFailed", c.Err())
+	}
+}
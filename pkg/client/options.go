@@ -0,0 +1,205 @@
+package client
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/bruxaodev/go-mp-server/pkg/server"
+	"github.com/quic-go/quic-go"
+)
+
+// Valores padrão do client
+const (
+	// DefaultKeepAlive é o intervalo dos PINGs do QUIC que mantêm a conexão viva
+	DefaultKeepAlive = 15 * time.Second
+	// DefaultReconnectBase e DefaultReconnectMax limitam a espera entre as
+	// tentativas de reconexão, que dobra a cada falha (com jitter)
+	DefaultReconnectBase = 500 * time.Millisecond
+	DefaultReconnectMax  = 30 * time.Second
+)
+
+// Option configura o Client
+type Option func(*options)
+
+type options struct {
+	tlsConfig       *tls.Config
+	alpn            []string
+	quicConfig      *quic.Config
+	codec           server.Codec
+	framing         bool
+	compression     server.Compression
+	maxMessageSize  int
+	keepAlive       time.Duration
+	authToken       string
+	protocolVersion int
+	session         bool
+	reconnect       bool
+	maxAttempts     int
+	reconnectBase   time.Duration
+	reconnectMax    time.Duration
+}
+
+func defaultOptions() options {
+	return options{
+		codec:          server.JSONCodec{},
+		maxMessageSize: server.DefaultMaxMessageSize,
+		keepAlive:      DefaultKeepAlive,
+		reconnect:      true,
+		reconnectBase:  DefaultReconnectBase,
+		reconnectMax:   DefaultReconnectMax,
+	}
+}
+
+// WithTLSConfig usa a configuração TLS fornecida (padrão: verificação pelas raízes do sistema)
+func WithTLSConfig(c *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = c
+	}
+}
+
+// WithInsecureSkipVerify aceita qualquer certificado do servidor, como o
+// autoassinado padrão. Use só em desenvolvimento.
+func WithInsecureSkipVerify() Option {
+	return func(o *options) {
+		if o.tlsConfig == nil {
+			o.tlsConfig = &tls.Config{}
+		}
+		o.tlsConfig.InsecureSkipVerify = true
+	}
+}
+
+// WithALPN define os protocolos ALPN oferecidos (padrão: server.DefaultALPN)
+func WithALPN(protocols ...string) Option {
+	return func(o *options) {
+		o.alpn = protocols
+	}
+}
+
+// WithQUICConfig substitui a configuração QUIC; datagramas e o keepalive são
+// habilitados nela de qualquer forma
+func WithQUICConfig(c *quic.Config) Option {
+	return func(o *options) {
+		o.quicConfig = c
+	}
+}
+
+// WithCodec define o codec das mensagens; deve ser o mesmo do servidor (padrão: JSON)
+func WithCodec(c server.Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// WithFraming envia as mensagens numa stream persistente com prefixo de
+// comprimento e lê as do servidor do mesmo modo. Deve casar com o WithFraming do servidor.
+func WithFraming(enabled bool) Option {
+	return func(o *options) {
+		o.framing = enabled
+	}
+}
+
+// WithCompression anuncia ao servidor, pelo ALPN (server.GzipALPNSuffix), que
+// o client aceita frames comprimidos e, se o servidor concordar, comprime as
+// mensagens enviadas a partir de server.DefaultCompressionThreshold bytes.
+// Requer WithFraming.
+func WithCompression(algo server.Compression) Option {
+	return func(o *options) {
+		o.compression = algo
+	}
+}
+
+// WithMaxMessageSize limita o tamanho das mensagens recebidas (0 = sem limite)
+func WithMaxMessageSize(bytes int) Option {
+	return func(o *options) {
+		o.maxMessageSize = bytes
+	}
+}
+
+// WithKeepAlive define o intervalo dos PINGs do QUIC (padrão: DefaultKeepAlive;
+// 0 desliga). Deve ser menor que o idle timeout do servidor.
+func WithKeepAlive(d time.Duration) Option {
+	return func(o *options) {
+		o.keepAlive = d
+	}
+}
+
+// WithAuthToken envia o token no handshake de auth de cada conexão, para
+// servidores com WithAuthenticator
+func WithAuthToken(token string) Option {
+	return func(o *options) {
+		o.authToken = token
+	}
+}
+
+// WithProtocolVersion faz o handshake de versão de cada conexão, para
+// servidores com RegisterProtocolVersion
+func WithProtocolVersion(v int) Option {
+	return func(o *options) {
+		o.protocolVersion = v
+	}
+}
+
+// WithSession faz o handshake de sessão de cada conexão, para servidores com
+// WithReconnectWindow: na reconexão o client reapresenta o token recebido e
+// retoma o mesmo client no servidor
+func WithSession() Option {
+	return func(o *options) {
+		o.session = true
+	}
+}
+
+// WithReconnect define as tentativas de reconexão após uma queda (0 = sem
+// limite) e a espera entre elas, que começa em base e dobra até max, com jitter
+func WithReconnect(maxAttempts int, base, max time.Duration) Option {
+	return func(o *options) {
+		o.reconnect = true
+		o.maxAttempts = maxAttempts
+		if base > 0 {
+			o.reconnectBase = base
+		}
+		if max > 0 {
+			o.reconnectMax = max
+		}
+	}
+}
+
+// WithoutReconnect desliga a reconexão automática: o client termina quando a conexão cai
+func WithoutReconnect() Option {
+	return func(o *options) {
+		o.reconnect = false
+	}
+}
+
+func (o options) tlsConf() *tls.Config {
+	conf := &tls.Config{}
+	if o.tlsConfig != nil {
+		conf = o.tlsConfig.Clone()
+	}
+	if len(conf.NextProtos) == 0 {
+		conf.NextProtos = o.alpn
+		if len(conf.NextProtos) == 0 {
+			conf.NextProtos = []string{server.DefaultALPN}
+		}
+	}
+	if o.compression == server.CompressionGzip {
+		// A variante com sufixo vem antes, mas a escolha final é do servidor
+		protos := make([]string, 0, 2*len(conf.NextProtos))
+		for _, p := range conf.NextProtos {
+			protos = append(protos, p+server.GzipALPNSuffix, p)
+		}
+		conf.NextProtos = protos
+	}
+	return conf
+}
+
+func (o options) quicConf() *quic.Config {
+	conf := &quic.Config{}
+	if o.quicConfig != nil {
+		conf = o.quicConfig.Clone()
+	}
+	conf.EnableDatagrams = true
+	if o.keepAlive > 0 {
+		conf.KeepAlivePeriod = o.keepAlive
+	}
+	return conf
+}
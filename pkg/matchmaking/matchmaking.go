@@ -0,0 +1,317 @@
+// Package matchmaking agrupa os jogadores de uma fila em partidas, usando as
+// salas do servidor: quem entra na fila vai para a sala do lobby e, ao formar
+// uma partida, o grupo é movido para uma sala própria.
+package matchmaking
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bruxaodev/go-mp-server/pkg/server"
+)
+
+// Erros retornados pelo Lobby, para comparação com errors.Is
+var (
+	// ErrAlreadyQueued indica que um dos jogadores do ticket já está na fila
+	ErrAlreadyQueued = errors.New("player already queued")
+	// ErrPartyTooLarge indica um ticket com mais jogadores que o MaxSize
+	ErrPartyTooLarge = errors.New("party larger than max group size")
+	// ErrEmptyTicket indica um ticket sem jogadores
+	ErrEmptyTicket = errors.New("ticket without players")
+)
+
+// DefaultLobbyRoom é a sala em que os jogadores esperam na fila
+const DefaultLobbyRoom = "lobby"
+
+// Ticket é uma entrada na fila: um jogador sozinho ou uma party que deve
+// cair junta na mesma partida
+type Ticket[T any] struct {
+	Players []T
+	Skill   float64
+	Region  string
+	// Attrs guarda critérios extras para um Compatible próprio
+	Attrs map[string]any
+	// EnqueuedAt é preenchido pelo Enqueue
+	EnqueuedAt time.Time
+}
+
+// Compatible decide se dois tickets podem cair na mesma partida. relaxed é
+// true quando algum dos dois já esperou mais que o QueueTimeout.
+type Compatible[T any] func(a, b *Ticket[T], relaxed bool) bool
+
+// Config define como os grupos são formados
+type Config[T any] struct {
+	// MinSize e MaxSize limitam o número de jogadores por partida (padrão 2 e MinSize)
+	MinSize int
+	MaxSize int
+	// SkillRange é a diferença máxima de Skill entre os tickets (0 = ignora a skill)
+	SkillRange float64
+	// QueueTimeout relaxa os critérios dos tickets que esperam há mais tempo;
+	// no Compatible padrão região e skill deixam de ser exigidas (0 = nunca)
+	QueueTimeout time.Duration
+	// Interval é o intervalo entre as rodadas de matchmaking do Start (padrão 1s)
+	Interval time.Duration
+	// LobbyRoom é a sala dos jogadores na fila (padrão DefaultLobbyRoom)
+	LobbyRoom string
+	// RoomName gera o nome da sala de cada partida (padrão "match-<id>")
+	RoomName func(id uint64) string
+	// Compatible substitui o critério padrão de região e skill
+	Compatible Compatible[T]
+}
+
+// Lobby mantém a fila e forma as partidas. Os jogadores que implementam
+// server.RoomSetter têm a sala trocada ao entrar na fila e ao formar a partida.
+type Lobby[T comparable] struct {
+	// OnMatchFound recebe os jogadores de cada partida formada, já na sala da partida
+	OnMatchFound func(players []T)
+
+	cfg     Config[T]
+	mu      sync.Mutex
+	queue   []*Ticket[T]
+	queued  map[T]*Ticket[T]
+	matches uint64
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New cria um Lobby com a configuração dada, completando os valores padrão
+func New[T comparable](cfg Config[T]) (*Lobby[T], error) {
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = 2
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = cfg.MinSize
+	}
+	if cfg.MaxSize < cfg.MinSize {
+		return nil, fmt.Errorf("%w: group size %d-%d", server.ErrInvalidOption, cfg.MinSize, cfg.MaxSize)
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.LobbyRoom == "" {
+		cfg.LobbyRoom = DefaultLobbyRoom
+	}
+	if cfg.RoomName == nil {
+		cfg.RoomName = func(id uint64) string { return fmt.Sprintf("match-%d", id) }
+	}
+	if cfg.Compatible == nil {
+		cfg.Compatible = defaultCompatible[T](cfg.SkillRange)
+	}
+	return &Lobby[T]{cfg: cfg, queued: make(map[T]*Ticket[T])}, nil
+}
+
+// defaultCompatible exige a mesma região e skill dentro de skillRange, até o ticket ser relaxado
+func defaultCompatible[T any](skillRange float64) Compatible[T] {
+	return func(a, b *Ticket[T], relaxed bool) bool {
+		if relaxed {
+			return true
+		}
+		if a.Region != b.Region {
+			return false
+		}
+		return skillRange <= 0 || abs(a.Skill-b.Skill) <= skillRange
+	}
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Enqueue coloca o ticket na fila e move seus jogadores para a sala do lobby
+func (l *Lobby[T]) Enqueue(t Ticket[T]) error {
+	if len(t.Players) == 0 {
+		return ErrEmptyTicket
+	}
+	if len(t.Players) > l.cfg.MaxSize {
+		return fmt.Errorf("%w: %d players (max %d)", ErrPartyTooLarge, len(t.Players), l.cfg.MaxSize)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, p := range t.Players {
+		if _, ok := l.queued[p]; ok {
+			return ErrAlreadyQueued
+		}
+	}
+	ticket := &t
+	ticket.Players = append([]T(nil), t.Players...)
+	ticket.EnqueuedAt = time.Now()
+	l.queue = append(l.queue, ticket)
+	for _, p := range ticket.Players {
+		l.queued[p] = ticket
+		setRoom(p, l.cfg.LobbyRoom)
+	}
+	return nil
+}
+
+// Remove tira da fila o ticket do jogador (ex.: no OnDisc) e retorna se ele estava na fila.
+// A party inteira sai junto; a sala dos jogadores não é alterada.
+func (l *Lobby[T]) Remove(player T) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ticket, ok := l.queued[player]
+	if !ok {
+		return false
+	}
+	l.dequeue(ticket)
+	l.queue = deleteTicket(l.queue, ticket)
+	return true
+}
+
+// Queued retorna quantos jogadores estão na fila
+func (l *Lobby[T]) Queued() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.queued)
+}
+
+// dequeue deve ser chamado com mu travado
+func (l *Lobby[T]) dequeue(ticket *Ticket[T]) {
+	for _, p := range ticket.Players {
+		delete(l.queued, p)
+	}
+}
+
+func deleteTicket[T any](queue []*Ticket[T], ticket *Ticket[T]) []*Ticket[T] {
+	for i, t := range queue {
+		if t == ticket {
+			return append(queue[:i], queue[i+1:]...)
+		}
+	}
+	return queue
+}
+
+// Start roda uma rodada de Match a cada Interval até o Stop
+func (l *Lobby[T]) Start() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop != nil {
+		return
+	}
+	l.stop = make(chan struct{})
+	l.wg.Add(1)
+	go l.loop(l.stop)
+}
+
+// Stop encerra o loop do Start; a fila é mantida
+func (l *Lobby[T]) Stop() {
+	l.mu.Lock()
+	stop := l.stop
+	l.stop = nil
+	l.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		l.wg.Wait()
+	}
+}
+
+func (l *Lobby[T]) loop(stop chan struct{}) {
+	defer l.wg.Done()
+	ticker := time.NewTicker(l.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			l.Match()
+		}
+	}
+}
+
+// Match forma as partidas possíveis com a fila atual e retorna quantas foram
+// formadas. Os tickets mais antigos têm prioridade; cada grupo recebe até
+// MaxSize jogadores compatíveis entre si e só vira partida com pelo menos MinSize.
+func (l *Lobby[T]) Match() int {
+	l.mu.Lock()
+	groups := l.formGroups(time.Now())
+	l.mu.Unlock()
+
+	for _, g := range groups {
+		for _, p := range g.players {
+			setRoom(p, g.room)
+		}
+		if l.OnMatchFound != nil {
+			l.OnMatchFound(g.players)
+		}
+	}
+	return len(groups)
+}
+
+type match[T any] struct {
+	room    string
+	players []T
+}
+
+// formGroups deve ser chamado com mu travado
+func (l *Lobby[T]) formGroups(now time.Time) []match[T] {
+	sort.SliceStable(l.queue, func(i, j int) bool {
+		return l.queue[i].EnqueuedAt.Before(l.queue[j].EnqueuedAt)
+	})
+	var groups []match[T]
+	used := make(map[*Ticket[T]]bool)
+	for i, anchor := range l.queue {
+		if used[anchor] {
+			continue
+		}
+		group := []*Ticket[T]{anchor}
+		size := len(anchor.Players)
+		for _, t := range l.queue[i+1:] {
+			if size == l.cfg.MaxSize {
+				break
+			}
+			if used[t] || size+len(t.Players) > l.cfg.MaxSize || !l.fits(group, t, now) {
+				continue
+			}
+			group = append(group, t)
+			size += len(t.Players)
+		}
+		if size < l.cfg.MinSize {
+			continue
+		}
+		l.matches++
+		m := match[T]{room: l.cfg.RoomName(l.matches), players: make([]T, 0, size)}
+		for _, t := range group {
+			used[t] = true
+			l.dequeue(t)
+			m.players = append(m.players, t.Players...)
+		}
+		groups = append(groups, m)
+	}
+	if len(groups) > 0 {
+		remaining := l.queue[:0]
+		for _, t := range l.queue {
+			if !used[t] {
+				remaining = append(remaining, t)
+			}
+		}
+		clear(l.queue[len(remaining):])
+		l.queue = remaining
+	}
+	return groups
+}
+
+// fits verifica se t é compatível com todos os tickets do grupo
+func (l *Lobby[T]) fits(group []*Ticket[T], t *Ticket[T], now time.Time) bool {
+	for _, g := range group {
+		if !l.cfg.Compatible(g, t, l.relaxed(g, now) || l.relaxed(t, now)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *Lobby[T]) relaxed(t *Ticket[T], now time.Time) bool {
+	return l.cfg.QueueTimeout > 0 && now.Sub(t.EnqueuedAt) >= l.cfg.QueueTimeout
+}
+
+func setRoom(player any, room string) {
+	if r, ok := player.(server.RoomSetter); ok {
+		r.SetRoom(room)
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import "time"
+
+const (
+	// adaptiveOverrunTicks é quantos ticks seguidos acima do orçamento reduzem a taxa
+	adaptiveOverrunTicks = 5
+	// adaptiveCalmSeconds é por quanto tempo os ticks devem usar menos da metade
+	// do orçamento para a taxa voltar a subir
+	adaptiveCalmSeconds = 2
+)
+
+// WithAdaptiveTick ajusta a taxa de ticks automaticamente entre minRate e
+// maxRate: ticks que estouram o intervalo repetidamente reduzem a taxa e, com
+// folga sustentada, ela volta a subir. A taxa efetiva aparece em Stats().TickRate.
+func WithAdaptiveTick(minRate, maxRate int) Option {
+	return func(o *options) {
+		o.adaptiveMinRate = minRate
+		o.adaptiveMaxRate = maxRate
+	}
+}
+
+// adaptiveTick conta ticks lentos e folgados seguidos. Usado só pelo tickLoop.
+type adaptiveTick struct {
+	overruns int
+	calm     int
+}
+
+// observeTick ajusta a taxa conforme a duração do último tick em relação ao orçamento
+func (s *Server[T, M]) observeTick(took time.Duration) {
+	if s.opts.adaptiveMaxRate <= 0 {
+		return
+	}
+	a := &s.adaptive
+	budget := s.tickInterval()
+	rate := s.TickRate()
+	switch {
+	case took > budget:
+		a.calm = 0
+		a.overruns++
+		if a.overruns >= adaptiveOverrunTicks && rate > s.opts.adaptiveMinRate {
+			a.overruns = 0
+			s.SetTickRate(max(s.opts.adaptiveMinRate, rate*3/4))
+		}
+	case took < budget/2:
+		a.overruns = 0
+		a.calm++
+		if a.calm >= rate*adaptiveCalmSeconds && rate < s.opts.adaptiveMaxRate {
+			a.calm = 0
+			s.SetTickRate(min(s.opts.adaptiveMaxRate, rate+max(1, rate/4)))
+		}
+	default:
+		a.overruns, a.calm = 0, 0
+	}
+}
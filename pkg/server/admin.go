@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// StartAdmin sobe um servidor HTTP de administração com /debug/pprof, /stats
+// (Stats em JSON) e /clients (ListClients em JSON). Sem host no endereço (ex.:
+// ":6060") ele escuta só em localhost; use "0.0.0.0:6060" para expor em todas
+// as interfaces. O servidor é encerrado pelo Stop.
+func (s *Server[T, M]) StartAdmin(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("%w: admin address %q: %v", ErrInvalidOption, addr, err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return fmt.Errorf("admin listen %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, s.Stats())
+	})
+	mux.HandleFunc("/clients", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, s.ListClients())
+	})
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	s.adminMu.Lock()
+	s.admins = append(s.admins, srv)
+	s.adminMu.Unlock()
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.reportError("admin serve", ln.Addr(), true, err)
+		}
+	}()
+	return nil
+}
+
+func writeAdminJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// stopAdmin encerra os servidores de administração
+func (s *Server[T, M]) stopAdmin() {
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
+	for _, srv := range s.admins {
+		srv.Close()
+	}
+	s.admins = nil
+}
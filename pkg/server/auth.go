@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Tipos de mensagem usados no handshake de autenticação
+const (
+	MessageTypeAuth   = "auth"
+	MessageTypeAuthOK = "auth_ok"
+)
+
+// MetaKeyAuthClaims é a chave de meta onde as claims do token autenticado são salvas
+const MetaKeyAuthClaims = "auth.claims"
+
+// DefaultAuthTimeout é o tempo padrão para o client enviar a mensagem de auth
+const DefaultAuthTimeout = 10 * time.Second
+
+// Authenticator valida o token (ex.: JWT) enviado pelo client e retorna suas claims
+type Authenticator func(token string) (claims map[string]any, err error)
+
+// AuthRequest é o payload da mensagem de auth
+type AuthRequest struct {
+	Token string `json:"token"`
+}
+
+// WithAuthenticator exige que a primeira mensagem de cada conexão seja do tipo
+// "auth" com um token validado por fn, antes de OnConn e de qualquer OnMsg
+func WithAuthenticator(fn Authenticator) Option {
+	return func(o *options) {
+		o.authenticator = fn
+	}
+}
+
+// WithAuthTimeout define quanto tempo o client tem para se autenticar
+func WithAuthTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.authTimeout = d
+	}
+}
+
+// authenticate lê a mensagem de auth da primeira stream da conexão e valida o token
+func (s *Server[T, M]) authenticate(conn *Conn, client T) error {
+	stream, msg, err := s.readHandshake(conn, MessageTypeAuth)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAuthFailed, err)
+	}
+	defer stream.Close()
+	var req AuthRequest
+	if err := s.opts.codec.Unmarshal(msg.Data, &req); err != nil {
+		return fmt.Errorf("%w: %v", ErrAuthFailed, err)
+	}
+	claims, err := s.opts.authenticator(req.Token)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAuthFailed, err)
+	}
+	if c, ok := any(client).(ClientInterface); ok {
+		c.SetMeta(MetaKeyAuthClaims, claims)
+	}
+	return s.writeHandshake(stream, MessageTypeAuthOK, nil)
+}
+
+// readHandshake lê da próxima stream da conexão uma mensagem do tipo esperado,
+// respeitando o tempo limite de auth
+func (s *Server[T, M]) readHandshake(conn *Conn, msgType string) (*Stream, *Message, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.opts.authTimeout)
+	defer cancel()
+
+	stream, err := conn.AcceptStream(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetReadDeadline(deadline)
+	}
+	defer unblockReads(ctx, stream)()
+
+	var data []byte
+	if s.opts.framing {
+		data, err = readFrame(stream, s.opts.maxMessageSize)
+	} else {
+		data, err = s.readMessage(stream)
+	}
+	if err != nil && err != io.EOF {
+		stream.Close()
+		return nil, nil, err
+	}
+
+	var msg Message
+	if err := s.opts.codec.Unmarshal(data, &msg); err != nil || msg.Type != msgType {
+		stream.Close()
+		return nil, nil, fmt.Errorf("expected %q message", msgType)
+	}
+	conn.markFirstMessage()
+	return stream, &msg, nil
+}
+
+// writeHandshake responde o handshake na mesma stream
+func (s *Server[T, M]) writeHandshake(stream *Stream, msgType string, payload any) error {
+	reply, err := s.encode(msgType, payload)
+	if err != nil {
+		return err
+	}
+	if s.opts.framing {
+		return writeFrame(stream, reply)
+	}
+	_, err = stream.Write(reply)
+	return err
+}
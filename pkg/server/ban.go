@@ -0,0 +1,109 @@
+package server
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// banSweepInterval é o intervalo entre remoções de bans expirados
+const banSweepInterval = time.Minute
+
+// banList guarda os IPs banidos e até quando (tempo zero = permanente)
+type banList struct {
+	mu    sync.RWMutex
+	until map[string]time.Time
+}
+
+func newBanList() *banList {
+	return &banList{until: make(map[string]time.Time)}
+}
+
+// banKey normaliza o IP para que IPv4 e IPv4 mapeado em IPv6 coincidam
+func banKey(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return v4.String()
+	}
+	return ip.String()
+}
+
+// addrIP extrai o IP de um endereço de rede
+func addrIP(addr net.Addr) net.IP {
+	if udp, ok := addr.(*net.UDPAddr); ok {
+		return udp.IP
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+	return net.ParseIP(host)
+}
+
+func (b *banList) isBanned(addr net.Addr) bool {
+	ip := addrIP(addr)
+	if ip == nil {
+		return false
+	}
+	return b.contains(banKey(ip), time.Now())
+}
+
+func (b *banList) contains(key string, now time.Time) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	until, ok := b.until[key]
+	return ok && (until.IsZero() || now.Before(until))
+}
+
+// sweep remove os bans temporários expirados
+func (b *banList) sweep(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key, until := range b.until {
+		if !until.IsZero() && !now.Before(until) {
+			delete(b.until, key)
+		}
+	}
+}
+
+// Ban recusa novas conexões do IP até until (tempo zero = permanente) e
+// desconecta os clients conectados a partir dele
+func (s *Server[T, M]) Ban(ip net.IP, until time.Time) {
+	key := banKey(ip)
+	s.opts.bans.mu.Lock()
+	s.opts.bans.until[key] = until
+	s.opts.bans.mu.Unlock()
+
+	s.conns.Range(func(conn *Conn, _ T) bool {
+		if remote := addrIP(conn.RemoteAddr()); remote != nil && banKey(remote) == key {
+			conn.closeWithReason(ReasonKicked, CloseCodeBanned, ErrBanned.Error())
+		}
+		return true
+	})
+}
+
+// Unban remove o ban do IP
+func (s *Server[T, M]) Unban(ip net.IP) {
+	s.opts.bans.mu.Lock()
+	delete(s.opts.bans.until, banKey(ip))
+	s.opts.bans.mu.Unlock()
+}
+
+// IsBanned indica se o IP está banido no momento
+func (s *Server[T, M]) IsBanned(ip net.IP) bool {
+	return s.opts.bans.contains(banKey(ip), time.Now())
+}
+
+// banSweeper remove periodicamente os bans expirados até o servidor parar
+func (s *Server[T, M]) banSweeper() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(banSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.opts.bans.sweep(now)
+		}
+	}
+}
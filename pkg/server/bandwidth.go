@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithPerClientBandwidthQuota limita os bytes que cada client pode enviar e
+// receber por minuto (contados separadamente, 0 = sem limite). A janela é um
+// token bucket que repõe a cota aos poucos, então rajadas de até um minuto de
+// cota passam. A política de WithRateLimitPolicy vale também aqui: com
+// RateLimitDrop as mensagens recebidas além da cota são descartadas e os envios
+// retornam ErrBandwidthExceeded; com RateLimitDisconnect o client é desconectado.
+func WithPerClientBandwidthQuota(bytesPerMinute int64) Option {
+	return func(o *options) {
+		o.bandwidthQuota = bytesPerMinute
+	}
+}
+
+// bandwidthQuota guarda as cotas de entrada e saída de uma conexão
+type bandwidthQuota struct {
+	in         *tokenBucket
+	out        *tokenBucket
+	disconnect bool
+}
+
+func newBandwidthQuota(bytesPerMinute int64, policy RateLimitPolicy) *bandwidthQuota {
+	return &bandwidthQuota{
+		in:         newByteBucket(bytesPerMinute),
+		out:        newByteBucket(bytesPerMinute),
+		disconnect: policy == RateLimitDisconnect,
+	}
+}
+
+// newByteBucket cria um bucket de bytes que repõe bytesPerMinute por minuto
+func newByteBucket(bytesPerMinute int64) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(bytesPerMinute) / 60,
+		burst:  float64(bytesPerMinute),
+		tokens: float64(bytesPerMinute),
+		last:   time.Now(),
+	}
+}
+
+// BytesIn retorna os bytes de mensagens e datagramas recebidos pela conexão
+func (c *Conn) BytesIn() int64 {
+	return c.bytesIn.Load()
+}
+
+// BytesOut retorna os bytes de mensagens e datagramas enviados pela conexão
+func (c *Conn) BytesOut() int64 {
+	return c.bytesOut.Load()
+}
+
+// acceptBytes conta n bytes recebidos e retorna false se eles excederem a cota
+func (s *Server[T, M]) acceptBytes(conn *Conn, c T, n int) bool {
+	conn.bytesIn.Add(int64(n))
+	if conn.quota == nil || conn.quota.in.allowN(float64(n)) {
+		return true
+	}
+	if s.OnBandwidthExceeded != nil {
+		s.OnBandwidthExceeded(c, n)
+	}
+	if conn.quota.disconnect {
+		conn.closeWithReason(ReasonKicked, CloseCodeBandwidthExceeded, ErrBandwidthExceeded.Error())
+	}
+	return false
+}
+
+// reserveOut desconta n bytes da cota de saída antes de um envio
+func (c *Conn) reserveOut(n int) error {
+	if c.quota == nil || c.quota.out.allowN(float64(n)) {
+		return nil
+	}
+	if c.quota.disconnect {
+		c.closeWithReason(ReasonKicked, CloseCodeBandwidthExceeded, ErrBandwidthExceeded.Error())
+	}
+	return fmt.Errorf("%w: %d bytes", ErrBandwidthExceeded, n)
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultBroadcastQueueSize é a fila de saída por client usada pelo
+// WithOrderedBroadcasts quando WithSendQueue não é configurado
+const DefaultBroadcastQueueSize = 256
+
+// WithOrderedBroadcasts garante que broadcasts feitos de goroutines diferentes
+// cheguem a todos os clients na mesma ordem: cada broadcast é enfileirado na
+// fila de saída de todos os destinatários antes do próximo começar, e cada fila
+// é escrita em ordem pela goroutine do client, em paralelo com as dos demais.
+// Habilita WithSendQueue(DefaultBroadcastQueueSize) se nenhuma fila for configurada.
+func WithOrderedBroadcasts(enabled bool) Option {
+	return func(o *options) {
+		o.orderedBroadcasts = enabled
+	}
+}
+
+// broadcastTarget é um destinatário de broadcast já filtrado
+type broadcastTarget struct {
+	conn *Conn
+	id   string
+}
+
+// broadcastBatch agrega os resultados de um único broadcast
+type broadcastBatch struct {
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+func (b *broadcastBatch) fail(id string, err error) {
+	b.mu.Lock()
+	b.errs = append(b.errs, fmt.Errorf("client %s: %w", id, err))
+	b.mu.Unlock()
+}
+
+type broadcastJob struct {
+	target broadcastTarget
+	data   []byte
+	send   func(conn *Conn, data []byte) error
+	batch  *broadcastBatch
+}
+
+// broadcastPool distribui os envios de broadcast entre um número fixo de workers,
+// reaproveitados entre chamadas
+type broadcastPool struct {
+	jobs      chan broadcastJob
+	quit      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+	// enqueueMu separa os envios à fila do dreno feito pelo close: deliver
+	// enfileira com RLock e o close só drena depois de obter o Lock
+	enqueueMu sync.RWMutex
+}
+
+var errPoolClosed = errors.New("broadcast pool closed")
+
+func newBroadcastPool(workers int) *broadcastPool {
+	p := &broadcastPool{
+		jobs: make(chan broadcastJob, workers*4),
+		quit: make(chan struct{}),
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *broadcastPool) worker() {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.quit:
+			return
+		case j := <-p.jobs:
+			if err := j.send(j.target.conn, j.data); err != nil {
+				j.batch.fail(j.target.id, err)
+			}
+			j.batch.wg.Done()
+		}
+	}
+}
+
+// deliver envia data para todos os targets e aguarda a conclusão. Depois do
+// close os targets restantes falham com errPoolClosed em vez de enfileirar.
+func (p *broadcastPool) deliver(targets []broadcastTarget, data []byte, send func(*Conn, []byte) error) error {
+	batch := &broadcastBatch{}
+	p.enqueueMu.RLock()
+	for _, t := range targets {
+		batch.wg.Add(1)
+		if p.closed() {
+			batch.fail(t.id, errPoolClosed)
+			batch.wg.Done()
+			continue
+		}
+		select {
+		case p.jobs <- broadcastJob{target: t, data: data, send: send, batch: batch}:
+		case <-p.quit:
+			batch.fail(t.id, errPoolClosed)
+			batch.wg.Done()
+		}
+	}
+	p.enqueueMu.RUnlock()
+	batch.wg.Wait()
+	return errors.Join(batch.errs...)
+}
+
+func (p *broadcastPool) closed() bool {
+	select {
+	case <-p.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// close para os workers e falha os jobs que ficaram na fila, para que nenhum
+// deliver em andamento fique esperando; pode ser chamado mais de uma vez (Stop repetido)
+func (p *broadcastPool) close() {
+	p.closeOnce.Do(func() { close(p.quit) })
+	p.wg.Wait()
+	// Com o Lock nenhum deliver está enfileirando, e os próximos veem quit fechado
+	p.enqueueMu.Lock()
+	defer p.enqueueMu.Unlock()
+	for {
+		select {
+		case j := <-p.jobs:
+			j.batch.fail(j.target.id, errPoolClosed)
+			j.batch.wg.Done()
+		default:
+			return
+		}
+	}
+}
+
+// orderBroadcasts trava a ordem entre broadcasts quando WithOrderedBroadcasts
+// está ativo e retorna a função que a destrava
+func (s *Server[T, M]) orderBroadcasts() func() {
+	if !s.opts.orderedBroadcasts {
+		return func() {}
+	}
+	s.broadcastMu.Lock()
+	return s.broadcastMu.Unlock
+}
+
+// deliver envia data aos targets usando o pool, se configurado, ou em série.
+// Com WithOrderedBroadcasts os envios só enfileiram e são feitos em série sob a
+// trava de ordem, já que o pool não preservaria a ordem entre broadcasts.
+func (s *Server[T, M]) deliver(targets []broadcastTarget, data []byte, send func(*Conn, []byte) error) error {
+	if s.opts.orderedBroadcasts {
+		defer s.orderBroadcasts()()
+	} else if s.pool != nil {
+		return s.pool.deliver(targets, data, send)
+	}
+	var errs []error
+	for _, t := range targets {
+		if err := send(t.conn, data); err != nil {
+			errs = append(errs, fmt.Errorf("client %s: %w", t.id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
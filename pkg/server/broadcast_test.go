@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStopTwiceWithBroadcastWorkers(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithBroadcastWorkers(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	s.Stop()
+	s.Stop()
+}
+
+func TestBroadcastPoolDeliverRacingClose(t *testing.T) {
+	targets := make([]broadcastTarget, 64)
+	for i := range targets {
+		targets[i] = broadcastTarget{conn: &Conn{}, id: fmt.Sprint(i)}
+	}
+	send := func(*Conn, []byte) error { return nil }
+	for run := 0; run < 50; run++ {
+		p := newBroadcastPool(2)
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					p.deliver(targets, nil, send)
+				}
+			}()
+		}
+		p.close()
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("run %d: deliver hung after close", run)
+		}
+		if err := p.deliver(targets[:1], nil, send); !errors.Is(err, errPoolClosed) {
+			t.Fatalf("deliver after close = %v, want %v", err, errPoolClosed)
+		}
+	}
+}
+
+func TestBroadcastRacingStop(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithBroadcastWorkers(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	connectTestClients(t, ctx, s, 4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				s.BroadcastReliable(&Message{Type: "tick"})
+			}
+		}()
+	}
+	s.Stop()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("Broadcast hung after Stop")
+	}
+}
+
+// BenchmarkBroadcast mede o broadcast completo (serialização e escrita nas
+// streams), em série e com o pool de workers, para clients conectados pelo
+// transporte em memória. WithFraming mantém uma stream persistente por client.
+func BenchmarkBroadcast(b *testing.B) {
+	msg := &Message{Type: "state", Data: json.RawMessage(`{"x":1.5,"y":-2,"hp":100}`)}
+	for _, clients := range []int{10, 100} {
+		for _, workers := range []int{0, runtime.GOMAXPROCS(0)} {
+			b.Run(fmt.Sprintf("clients=%d/workers=%d", clients, workers), func(b *testing.B) {
+				s, err := NewTestServer(NewClient, NewMessage, WithBroadcastWorkers(workers), WithFraming(true))
+				if err != nil {
+					b.Fatal(err)
+				}
+				s.Start()
+				b.Cleanup(s.Stop)
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				defer cancel()
+				for _, tc := range connectTestClients(b, ctx, s, clients) {
+					// Consome as mensagens para o controle de fluxo não travar os envios
+					go func(tc *TestClient) {
+						for {
+							if _, err := tc.Receive(ctx); err != nil {
+								return
+							}
+						}
+					}(tc)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := s.BroadcastReliable(msg); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkBroadcastDatagram compara, com 500 clients, o envio de um datagrama
+// client a client com o BroadcastDatagram, em pacotes por segundo
+func BenchmarkBroadcastDatagram(b *testing.B) {
+	const clients = 500
+	s, err := NewTestServer(NewClient, NewMessage, WithBroadcastWorkers(runtime.GOMAXPROCS(0)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	s.Start()
+	b.Cleanup(s.Stop)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	connectTestClients(b, ctx, s, clients)
+	data := make([]byte, 64)
+
+	b.Run("per-client", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s.RangeClients(func(c *Client) bool {
+				c.SendDatagram(data)
+				return true
+			})
+		}
+		b.ReportMetric(float64(b.N*clients)/b.Elapsed().Seconds(), "pkts/s")
+	})
+	b.Run("BroadcastDatagram", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s.BroadcastDatagram(data)
+		}
+		b.ReportMetric(float64(b.N*clients)/b.Elapsed().Seconds(), "pkts/s")
+	})
+}
+
+// BenchmarkBroadcastStreams compara broadcasts por segundo abrindo uma stream
+// por mensagem (sem framing) com a stream de saída persistente do WithFraming
+func BenchmarkBroadcastStreams(b *testing.B) {
+	const clients = 50
+	msg := &Message{Type: "state", Data: json.RawMessage(`{"x":1.5,"y":-2,"hp":100}`)}
+	for _, framed := range []bool{false, true} {
+		b.Run(map[bool]string{false: "stream-per-message", true: "persistent"}[framed], func(b *testing.B) {
+			s, err := NewTestServer(NewClient, NewMessage, WithFraming(framed))
+			if err != nil {
+				b.Fatal(err)
+			}
+			s.Start()
+			b.Cleanup(s.Stop)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			for _, tc := range connectTestClients(b, ctx, s, clients) {
+				go func(tc *TestClient) {
+					for {
+						if _, err := tc.Receive(ctx); err != nil {
+							return
+						}
+					}
+				}(tc)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := s.BroadcastReliable(msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "broadcasts/s")
+		})
+	}
+}
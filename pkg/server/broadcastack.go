@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BroadcastAck envia a mensagem por stream para todos os clients e espera cada
+// escrita terminar, retornando os IDs dos clients entregues e dos que falharam.
+// "Entregue" quer dizer que a escrita foi aceita pela stream do QUIC (que cuida
+// da retransmissão), não que o client leu a mensagem. O prazo do ctx também vale
+// para as escritas: clients que não terminarem a tempo entram em failed e err
+// inclui ctx.Err(). Com WithSendQueue a mensagem entra na fila como qualquer
+// envio, e a espera inclui o tempo na fila.
+func (s *Server[T, M]) BroadcastAck(ctx context.Context, msg *Message) (delivered, failed []string, err error) {
+	data, err := marshalMessage(s.opts.codec, msg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal message: %w", err)
+	}
+	var targets []broadcastTarget
+	s.conns.Range(func(conn *Conn, client T) bool {
+		targets = append(targets, broadcastTarget{conn: conn, id: clientID(conn, client)})
+		return true
+	})
+	if len(targets) == 0 {
+		return nil, nil, nil
+	}
+
+	type result struct {
+		i   int
+		err error
+	}
+	deadline, _ := ctx.Deadline()
+	results := make(chan result, len(targets))
+	unlock := s.orderBroadcasts()
+	for i, t := range targets {
+		done, err := t.conn.sendAck(data, deadline)
+		if err != nil {
+			results <- result{i, err}
+			continue
+		}
+		go func(conn *Conn) {
+			select {
+			case err := <-done:
+				results <- result{i, err}
+			case <-conn.Context().Done():
+				// A fila de saída para junto com a conexão e pode não chegar à escrita
+				results <- result{i, ErrConnectionClosed}
+			}
+		}(t.conn)
+	}
+	unlock()
+
+	var errs []error
+	finished := make([]bool, len(targets))
+	for range targets {
+		select {
+		case r := <-results:
+			finished[r.i] = true
+			if r.err == nil {
+				delivered = append(delivered, targets[r.i].id)
+				continue
+			}
+			failed = append(failed, targets[r.i].id)
+			errs = append(errs, fmt.Errorf("client %s: %w", targets[r.i].id, r.err))
+		case <-ctx.Done():
+			for i, ok := range finished {
+				if !ok {
+					failed = append(failed, targets[i].id)
+				}
+			}
+			errs = append(errs, ctx.Err())
+			return delivered, failed, errors.Join(errs...)
+		}
+	}
+	return delivered, failed, errors.Join(errs...)
+}
+
+// sendAck escreve data (ou a enfileira) e retorna um canal com o resultado da escrita
+func (c *Conn) sendAck(data []byte, deadline time.Time) (<-chan error, error) {
+	if err := c.reserveOut(len(data)); err != nil {
+		return nil, err
+	}
+	done := make(chan error, 1)
+	write := func(c *Conn, data []byte) error {
+		err := c.closedErr(c.writeRawUntil(data, deadline))
+		c.countSent(1, len(data), err)
+		done <- err
+		return err
+	}
+	if c.queue != nil {
+		if err := c.queue.push(c, data, write); err != nil {
+			return nil, err
+		}
+		return done, nil
+	}
+	go write(c, data)
+	return done, nil
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBuffer evita que uma mensagem muito grande fique retida no pool
+const maxPooledBuffer = 64 << 10
+
+var encodeBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+func getBuffer() *bytes.Buffer {
+	buf := encodeBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// releaseBuffer devolve buf ao pool; buf nil é ignorado
+func releaseBuffer(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledBuffer {
+		return
+	}
+	encodeBuffers.Put(buf)
+}
+
+// marshalPooled serializa v num buffer do pool quando o codec implementa
+// BufferCodec. data só vale até buf ser devolvido com releaseBuffer; sem
+// pooling buf é nil e data pertence ao chamador.
+func marshalPooled(codec Codec, v any) (data []byte, buf *bytes.Buffer, err error) {
+	if v, err = typedForCodec(codec, v); err != nil {
+		return nil, nil, err
+	}
+	bc, ok := codec.(BufferCodec)
+	if !ok {
+		data, err = codec.Marshal(v)
+		return data, nil, err
+	}
+	buf = getBuffer()
+	if err := bc.MarshalTo(buf, v); err != nil {
+		releaseBuffer(buf)
+		return nil, nil, err
+	}
+	return buf.Bytes(), buf, nil
+}
+
+// marshalBroadcast serializa msg para um broadcast. Com fila de saída os bytes
+// ficam retidos depois do envio, então o buffer do pool não é usado.
+func (s *Server[T, M]) marshalBroadcast(msg *Message) ([]byte, *bytes.Buffer, error) {
+	if s.opts.sendQueueSize > 0 {
+		data, err := marshalMessage(s.opts.codec, msg)
+		return data, nil, err
+	}
+	return marshalPooled(s.opts.codec, msg)
+}
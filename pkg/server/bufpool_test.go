@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// BenchmarkMarshalPooled compara a serialização com buffer do pool com o
+// Marshal que aloca um slice novo a cada mensagem
+func BenchmarkMarshalPooled(b *testing.B) {
+	msg := &Message{Type: "state", Data: json.RawMessage(`{"x":1.5,"y":-2,"hp":100,"team":"red"}`)}
+	codec := JSONCodec{}
+	b.Run("marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := marshalMessage(codec, msg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, buf, err := marshalPooled(codec, msg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			releaseBuffer(buf)
+		}
+	})
+}
+
+// BenchmarkClientIteration compara o GetClients, que monta uma slice a cada
+// chamada, com o RangeClients, para 1.000 clients percorridos a cada tick
+func BenchmarkClientIteration(b *testing.B) {
+	s := &Server[*Client, *Message]{}
+	for i := 0; i < 1000; i++ {
+		conn := &Conn{seq: uint64(i)}
+		s.conns.Store(conn, &Client{ID: fmt.Sprint(i), Conn: conn})
+	}
+	var sink int
+	b.Run("GetClients", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, c := range s.GetClients() {
+				sink += len(c.ID)
+			}
+		}
+	})
+	b.Run("RangeClients", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s.RangeClients(func(c *Client) bool {
+				sink += len(c.ID)
+				return true
+			})
+		}
+	})
+	_ = sink
+}
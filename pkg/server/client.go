@@ -1,8 +1,17 @@
 package server
 
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
 type ClientInterface interface {
 	GetID() string
 	GetConn() *Conn
+	GetRemoteAddr() net.Addr
 	GetMeta() map[string]interface{}
 	SetID(id string)
 	SetMeta(key string, value interface{})
@@ -22,6 +31,15 @@ func (c *Client) GetConn() *Conn {
 	return c.Conn
 }
 
+// SetConn religa o client a uma nova conexão ao retomar a sessão
+func (c *Client) SetConn(conn *Conn) {
+	c.Conn = conn
+}
+
+func (c *Client) GetRemoteAddr() net.Addr {
+	return c.Conn.RemoteAddr()
+}
+
 func (c *Client) GetMeta() map[string]interface{} {
 	return c.Meta
 }
@@ -37,6 +55,47 @@ func (c *Client) SetMeta(key string, value interface{}) {
 	c.Meta[key] = value
 }
 
+// Send envia uma mensagem para o client de forma segura entre goroutines
+func (c *Client) Send(msg *Message) error {
+	return c.Conn.Send(msg)
+}
+
+// SendReliable envia uma mensagem por stream, com entrega garantida
+func (c *Client) SendReliable(msg *Message) error {
+	return c.Conn.SendReliable(msg)
+}
+
+// SendUnreliable envia uma mensagem por datagrama, com fallback para stream se exceder o MTU
+func (c *Client) SendUnreliable(msg *Message) error {
+	return c.Conn.SendUnreliable(msg)
+}
+
+// SendDatagram envia dados não confiáveis via datagrama para o client
+func (c *Client) SendDatagram(data []byte) error {
+	return c.Conn.SendDatagram(data)
+}
+
+// RTT retorna o RTT suavizado da conexão do client
+func (c *Client) RTT() time.Duration {
+	return c.Conn.RTT()
+}
+
+// QUICStats retorna as estatísticas de transporte da conexão do client
+func (c *Client) QUICStats() ConnStats {
+	return c.Conn.QUICStats()
+}
+
+// MaxDatagramSize retorna o maior payload aceito por SendDatagram no momento
+func (c *Client) MaxDatagramSize() int {
+	return c.Conn.MaxDatagramSize()
+}
+
+// Close desconecta o client com o código e motivo informados. O OnDisc
+// é chamado normalmente com ReasonClientClosed.
+func (c *Client) Close(code uint64, reason string) error {
+	return c.Conn.closeWithReason(ReasonClientClosed, quic.ApplicationErrorCode(code), reason)
+}
+
 func NewClient(conn *Conn) *Client {
 	return &Client{
 		ID:   "",
@@ -44,3 +103,29 @@ func NewClient(conn *Conn) *Client {
 		Meta: make(map[string]interface{}),
 	}
 }
+
+// Context retorna um contexto cancelado quando a conexão atual do client termina.
+// Ao retomar a sessão (WithReconnectWindow) o client ganha uma conexão e um contexto novos.
+func (c *Client) Context() context.Context {
+	if c.Conn == nil {
+		return closedContext
+	}
+	return c.Conn.Context()
+}
+
+// closedContext é o contexto de clients sem conexão
+var closedContext = func() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}()
+
+// BytesIn retorna os bytes recebidos do client na conexão atual
+func (c *Client) BytesIn() int64 {
+	return c.Conn.BytesIn()
+}
+
+// BytesOut retorna os bytes enviados ao client na conexão atual
+func (c *Client) BytesOut() int64 {
+	return c.Conn.BytesOut()
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"slices"
+	"sync"
+)
+
+// clientShards é o número de shards do clientStore; potência de 2 para o índice virar máscara
+const clientShards = 32
+
+// clientStore guarda os clients conectados em shards com mutex próprio. Ao
+// contrário do sync.Map, o Range percorre slices tipadas sem boxing, o que pesa
+// no padrão de acesso do servidor (iteração completa a cada tick e broadcast).
+type clientStore[T any] struct {
+	shards [clientShards]clientShard[T]
+}
+
+// clientShard mantém os clients numa slice densa e um índice para Load/Delete
+// em O(1). A slice nunca é alterada nas posições já publicadas (Store só faz
+// append e Delete copia), então o Range percorre uma cópia do cabeçalho sem trava.
+type clientShard[T any] struct {
+	mu      sync.RWMutex
+	entries []clientEntry[T]
+	index   map[*Conn]int
+}
+
+type clientEntry[T any] struct {
+	conn   *Conn
+	client T
+}
+
+// shard escolhe o shard pela sequência da conexão, distribuindo as conexões em rodízio
+func (cs *clientStore[T]) shard(conn *Conn) *clientShard[T] {
+	return &cs.shards[conn.seq&(clientShards-1)]
+}
+
+func (cs *clientStore[T]) Load(conn *Conn) (T, bool) {
+	sh := cs.shard(conn)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	if i, ok := sh.index[conn]; ok {
+		return sh.entries[i].client, true
+	}
+	var zero T
+	return zero, false
+}
+
+func (cs *clientStore[T]) Store(conn *Conn, c T) {
+	sh := cs.shard(conn)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if i, ok := sh.index[conn]; ok {
+		entries := slices.Clone(sh.entries)
+		entries[i].client = c
+		sh.entries = entries
+		return
+	}
+	if sh.index == nil {
+		sh.index = make(map[*Conn]int)
+	}
+	sh.index[conn] = len(sh.entries)
+	sh.entries = append(sh.entries, clientEntry[T]{conn: conn, client: c})
+}
+
+func (cs *clientStore[T]) Delete(conn *Conn) {
+	sh := cs.shard(conn)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	i, ok := sh.index[conn]
+	if !ok {
+		return
+	}
+	// Copia movendo o último para a posição removida
+	last := len(sh.entries) - 1
+	entries := make([]clientEntry[T], last, max(last, 8))
+	copy(entries, sh.entries[:last])
+	if i != last {
+		entries[i] = sh.entries[last]
+		sh.index[entries[i].conn] = i
+	}
+	sh.entries = entries
+	delete(sh.index, conn)
+}
+
+// Range chama fn para cada client até fn retornar false. fn roda sem trava e
+// pode registrar ou remover clients; cada shard é visto como estava ao começar
+// a percorrê-lo.
+func (cs *clientStore[T]) Range(fn func(conn *Conn, c T) bool) {
+	for i := range cs.shards {
+		sh := &cs.shards[i]
+		sh.mu.RLock()
+		entries := sh.entries
+		sh.mu.RUnlock()
+		for _, e := range entries {
+			if !fn(e.conn, e.client) {
+				return
+			}
+		}
+	}
+}
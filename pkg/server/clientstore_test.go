@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestClientStoreStoreLoadDelete(t *testing.T) {
+	var cs clientStore[string]
+	conns := make([]*Conn, 100)
+	for i := range conns {
+		conns[i] = &Conn{seq: uint64(i)}
+		cs.Store(conns[i], fmt.Sprint(i))
+	}
+	for i := 0; i < len(conns); i += 2 {
+		cs.Delete(conns[i])
+	}
+	seen := 0
+	cs.Range(func(conn *Conn, c string) bool {
+		if conn.seq%2 == 0 || c != fmt.Sprint(conn.seq) {
+			t.Fatalf("unexpected entry %d = %q", conn.seq, c)
+		}
+		seen++
+		return true
+	})
+	if seen != 50 {
+		t.Fatalf("Range saw %d clients, want 50", seen)
+	}
+	if _, ok := cs.Load(conns[0]); ok {
+		t.Fatal("deleted client is still loaded")
+	}
+	if c, ok := cs.Load(conns[1]); !ok || c != "1" {
+		t.Fatalf("Load = %q, %v", c, ok)
+	}
+}
+
+// BenchmarkClientStore compara o clientStore com o sync.Map que ele substituiu,
+// nos acessos do tick e do broadcast: percorrer todos os clients e montar os
+// destinatários, e buscar um client pela conexão
+func BenchmarkClientStore(b *testing.B) {
+	const clients = 1000
+	var cs clientStore[*Client]
+	var sm sync.Map
+	conns := make([]*Conn, clients)
+	for i := range conns {
+		conns[i] = &Conn{seq: uint64(i)}
+		c := &Client{ID: fmt.Sprint(i), Conn: conns[i]}
+		cs.Store(conns[i], c)
+		sm.Store(conns[i], c)
+	}
+	targets := make([]broadcastTarget, 0, clients)
+
+	b.Run("range/sync.Map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			targets = targets[:0]
+			sm.Range(func(k, v any) bool {
+				targets = append(targets, broadcastTarget{conn: k.(*Conn), id: v.(*Client).ID})
+				return true
+			})
+		}
+	})
+	b.Run("range/clientStore", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			targets = targets[:0]
+			cs.Range(func(conn *Conn, c *Client) bool {
+				targets = append(targets, broadcastTarget{conn: conn, id: c.ID})
+				return true
+			})
+		}
+	})
+	b.Run("load/sync.Map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			v, _ := sm.Load(conns[i%clients])
+			_ = v.(*Client)
+		}
+	})
+	b.Run("load/clientStore", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cs.Load(conns[i%clients])
+		}
+	})
+}
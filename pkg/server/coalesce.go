@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// WithTickCoalescing faz o Enqueue acumular as mensagens do tick e enviá-las
+// juntas ao fim dele, numa única escrita da stream de saída de cada client.
+// Requer WithFraming; sem framing o Enqueue envia cada mensagem na hora.
+func WithTickCoalescing(enabled bool) Option {
+	return func(o *options) {
+		o.tickCoalescing = enabled
+	}
+}
+
+// tickBatch guarda os frames enfileirados durante o tick atual. Com
+// WithCompression zbuf guarda os mesmos frames comprimidos, para os clients
+// que negociaram a compressão.
+type tickBatch struct {
+	mu   sync.Mutex
+	buf  *bytes.Buffer
+	zbuf *bytes.Buffer
+	n    int64
+}
+
+// Enqueue agenda msg para todos os clients, com entrega garantida e ordenada.
+// Com WithTickCoalescing a mensagem é serializada na hora e enviada no fim do
+// tick junto com as demais do mesmo tick; caso contrário equivale a BroadcastReliable.
+func (s *Server[T, M]) Enqueue(msg *Message) error {
+	if !s.opts.tickCoalescing || !s.opts.framing {
+		return s.BroadcastReliable(msg)
+	}
+	data, buf, err := marshalPooled(s.opts.codec, msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	defer releaseBuffer(buf)
+	s.batch.mu.Lock()
+	defer s.batch.mu.Unlock()
+	if s.batch.buf == nil {
+		s.batch.buf = getBuffer()
+	}
+	if err := writeFrame(s.batch.buf, data); err != nil {
+		return err
+	}
+	if s.opts.compression != CompressionNone {
+		if s.batch.zbuf == nil {
+			s.batch.zbuf = getBuffer()
+		}
+		if err := writeFrameCompressed(s.batch.zbuf, data, s.opts.compression, s.opts.compressThreshold); err != nil {
+			return err
+		}
+	}
+	s.batch.n++
+	return nil
+}
+
+// flushTickBatch envia o lote do tick para todos os clients
+func (s *Server[T, M]) flushTickBatch() {
+	s.batch.mu.Lock()
+	buf, zbuf, n := s.batch.buf, s.batch.zbuf, s.batch.n
+	s.batch.buf, s.batch.zbuf, s.batch.n = nil, nil, 0
+	s.batch.mu.Unlock()
+	if buf == nil {
+		return
+	}
+	var plain, compressed []broadcastTarget
+	s.conns.Range(func(conn *Conn, client T) bool {
+		t := broadcastTarget{conn: conn, id: clientID(conn, client)}
+		if zbuf != nil && conn.compression != CompressionNone {
+			compressed = append(compressed, t)
+		} else {
+			plain = append(plain, t)
+		}
+		return true
+	})
+	send := func(c *Conn, frames []byte) error { return c.sendFrames(frames, n) }
+	if err := s.deliver(plain, buf.Bytes(), send); err != nil {
+		log.Println("tick batch error:", err)
+	}
+	if len(compressed) > 0 {
+		if err := s.deliver(compressed, zbuf.Bytes(), send); err != nil {
+			log.Println("tick batch error:", err)
+		}
+	}
+	if s.opts.sendQueueSize == 0 {
+		// Com fila de saída o lote ainda pode estar enfileirado
+		releaseBuffer(buf)
+		releaseBuffer(zbuf)
+	}
+}
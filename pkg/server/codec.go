@@ -0,0 +1,39 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Codec define a serialização usada pelo servidor para envelopes e payloads
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// BufferCodec é um Codec que sabe serializar direto num buffer. O servidor usa
+// MarshalTo com buffers reaproveitados entre envios em vez de alocar a cada Marshal.
+type BufferCodec interface {
+	Codec
+	MarshalTo(buf *bytes.Buffer, v any) error
+}
+
+// JSONCodec é o Codec padrão, baseado em encoding/json
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// MarshalTo produz os mesmos bytes que Marshal, sem a quebra de linha do json.Encoder
+func (JSONCodec) MarshalTo(buf *bytes.Buffer, v any) error {
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
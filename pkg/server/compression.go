@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// Compression identifica o algoritmo usado para comprimir frames de saída
+type Compression int
+
+const (
+	// CompressionNone desabilita a compressão
+	CompressionNone Compression = iota
+	// CompressionGzip comprime os frames com gzip
+	CompressionGzip
+)
+
+// DefaultCompressionThreshold é o tamanho mínimo padrão para comprimir um frame
+const DefaultCompressionThreshold = 1024
+
+// frameCompressedFlag marca, no prefixo de comprimento, um frame comprimido
+const frameCompressedFlag = 1 << 31
+
+// GzipALPNSuffix é somado aos protocolos ALPN do jogo para o client anunciar
+// que aceita frames gzip (ex.: "go-mp/1+gzip"). O servidor só comprime para
+// clients que negociaram um protocolo com o sufixo.
+const GzipALPNSuffix = "+gzip"
+
+// WithCompression comprime os frames de saída maiores que o limite de
+// WithCompressionThreshold para os clients que anunciam suporte pelo ALPN
+// (GzipALPNSuffix); os demais recebem frames sem compressão. Requer
+// WithFraming; o receptor detecta e descomprime os frames automaticamente, e o
+// servidor também aceita frames comprimidos vindos desses clients.
+func WithCompression(algo Compression) Option {
+	return func(o *options) {
+		o.compression = algo
+	}
+}
+
+// WithCompressionThreshold define o tamanho mínimo em bytes de um frame para
+// que ele seja comprimido (padrão: DefaultCompressionThreshold)
+func WithCompressionThreshold(bytes int) Option {
+	return func(o *options) {
+		o.compressThreshold = bytes
+	}
+}
+
+// WriteFrameCompressed escreve data no formato do WithFraming, comprimido com
+// algo a partir de threshold bytes, para clients em Go que negociaram a compressão
+func WriteFrameCompressed(w io.Writer, data []byte, algo Compression, threshold int) error {
+	return writeFrameCompressed(w, data, algo, threshold)
+}
+
+// compressionALPN acrescenta, antes de cada protocolo, a variante com
+// GzipALPNSuffix. O crypto/tls escolhe pela ordem do servidor, então clients
+// que oferecem as duas negociam a compressão.
+func compressionALPN(protos []string) []string {
+	out := make([]string, 0, 2*len(protos))
+	for _, p := range protos {
+		out = append(out, p+GzipALPNSuffix, p)
+	}
+	return out
+}
+
+// negotiatedCompression retorna o algoritmo a usar com o client que negociou
+// o protocolo ALPN proto
+func negotiatedCompression(algo Compression, proto string) Compression {
+	if algo == CompressionGzip && strings.HasSuffix(proto, GzipALPNSuffix) {
+		return CompressionGzip
+	}
+	return CompressionNone
+}
+
+// writeFrameCompressed escreve data como frame, comprimido quando ele atinge o
+// limite e a compressão de fato reduz o tamanho
+func writeFrameCompressed(w io.Writer, data []byte, algo Compression, threshold int) error {
+	if algo != CompressionGzip || len(data) < threshold {
+		return writeFrame(w, data)
+	}
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if buf.Len() >= len(data) {
+		return writeFrame(w, data)
+	}
+	return writeFrameHeader(w, uint32(buf.Len())|frameCompressedFlag, buf.Bytes())
+}
+
+// decompressFrame descomprime um frame gzip, respeitando limit no tamanho final
+func decompressFrame(data []byte, limit int) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	var r io.Reader = zr
+	if limit > 0 {
+		r = io.LimitReader(zr, int64(limit)+1)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(out) > limit {
+		return nil, ErrMessageTooLarge
+	}
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+func TestCompressionNegotiatedPerClient(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithFraming(true), WithCompression(CompressionGzip), WithCompressionThreshold(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	t.Cleanup(s.Stop)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	capable := connectTestClients(t, ctx, s, 1)[0]
+
+	// Um client que só oferece o ALPN sem sufixo não anunciou suporte a gzip
+	srv := s.listeners[0].tr.Conn.(*memPacketConn)
+	tr := &quic.Transport{Conn: srv.network.listen()}
+	defer tr.Close()
+	legacy, err := tr.Dial(ctx, srv.addr, &tls.Config{InsecureSkipVerify: true, NextProtos: []string{DefaultALPN}}, &quic.Config{EnableDatagrams: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer legacy.CloseWithError(0, "")
+	waitFor(t, ctx, func() bool { return len(s.GetClients()) == 2 })
+
+	want := map[string]Compression{
+		capable.Conn.LocalAddr().String(): CompressionGzip,
+		legacy.LocalAddr().String():       CompressionNone,
+	}
+	s.conns.Range(func(conn *Conn, _ *Client) bool {
+		if got := conn.compression; got != want[conn.RemoteAddr().String()] {
+			t.Errorf("client %s compression = %v, want %v", conn.RemoteAddr(), got, want[conn.RemoteAddr().String()])
+		}
+		return true
+	})
+
+	payload := json.RawMessage(`"` + string(bytes.Repeat([]byte("a"), 4096)) + `"`)
+	if err := s.BroadcastReliable(&Message{Type: "snapshot", Data: payload}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := capable.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(msg.Data, payload) {
+		t.Fatal("compressed snapshot did not round-trip")
+	}
+
+	// O client sem suporte recebe o frame sem o bit de compressão
+	str, err := legacy.AcceptStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hdr [frameHeaderSize]byte
+	if _, err := str.Read(hdr[:]); err != nil {
+		t.Fatal(err)
+	}
+	if hdr[0]&0x80 != 0 {
+		t.Fatal("server compressed a frame for a client that did not negotiate gzip")
+	}
+}
+
+// BenchmarkCompressionSnapshot mede os bytes na rede de um snapshot JSON de
+// 500 entidades com e sem compressão
+func BenchmarkCompressionSnapshot(b *testing.B) {
+	type entity struct {
+		ID   string  `json:"id"`
+		X    float64 `json:"x"`
+		Y    float64 `json:"y"`
+		HP   int     `json:"hp"`
+		Team string  `json:"team"`
+	}
+	entities := make([]entity, 500)
+	for i := range entities {
+		entities[i] = entity{ID: fmt.Sprint("entity-", i), X: float64(i % 50), Y: float64(i / 50), HP: 100, Team: "red"}
+	}
+	data, err := json.Marshal(&Message{Type: "snapshot", Data: mustMarshal(b, entities)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, algo := range []Compression{CompressionNone, CompressionGzip} {
+		b.Run(map[Compression]string{CompressionNone: "none", CompressionGzip: "gzip"}[algo], func(b *testing.B) {
+			var buf bytes.Buffer
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := writeFrameCompressed(&buf, data, algo, DefaultCompressionThreshold); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(buf.Len()), "wire-B/op")
+		})
+	}
+}
+
+func mustMarshal(tb testing.TB, v any) json.RawMessage {
+	tb.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/webtransport-go"
+)
+
+// DisconnectReason identifica o motivo de uma desconexão
+type DisconnectReason int
+
+const (
+	// ReasonError indica uma falha de rede ou de transporte
+	ReasonError DisconnectReason = iota
+	// ReasonClientClosed indica que o client encerrou a conexão normalmente
+	ReasonClientClosed
+	// ReasonIdleTimeout indica que a conexão expirou por inatividade
+	ReasonIdleTimeout
+	// ReasonKicked indica que o servidor encerrou a conexão do client
+	ReasonKicked
+	// ReasonServerShutdown indica que o servidor está sendo desligado
+	ReasonServerShutdown
+)
+
+func (r DisconnectReason) String() string {
+	switch r {
+	case ReasonClientClosed:
+		return "client closed"
+	case ReasonIdleTimeout:
+		return "idle timeout"
+	case ReasonKicked:
+		return "kicked"
+	case ReasonServerShutdown:
+		return "server shutdown"
+	default:
+		return "error"
+	}
+}
+
+// DisconnectInfo descreve uma desconexão entregue ao OnDisc
+type DisconnectInfo struct {
+	Reason DisconnectReason
+	Err    error
+	// Client é um retrato do client (ID, sala, tags) tirado quando a conexão
+	// termina, depois que os handlers de mensagem do client retornaram. As tags
+	// são copiadas, então o retrato não muda se o client for alterado depois;
+	// alterações vindas de outras goroutines (ex.: TickFn) continuam precisando
+	// da sincronização do próprio client.
+	Client PresenceEntry
+	// Duration é quanto tempo a conexão durou
+	Duration time.Duration
+}
+
+// withSummary preenche o retrato do client, se ainda não houver um
+func (info DisconnectInfo) withSummary(conn *Conn, client any) DisconnectInfo {
+	if info.Client.ID != "" {
+		return info
+	}
+	info.Client = presenceEntry(conn, client)
+	if len(info.Client.Tags) == 0 {
+		info.Client.Tags = conn.tagIndex().tagsOf(conn)
+	} else {
+		// GetTags pode devolver o slice vivo do client
+		info.Client.Tags = slices.Clone(info.Client.Tags)
+	}
+	info.Duration = time.Since(conn.connectedAt)
+	return info
+}
+
+// closeWithReason fecha a conexão registrando o motivo que será entregue ao OnDisc
+func (c *Conn) closeWithReason(reason DisconnectReason, code quic.ApplicationErrorCode, desc string) error {
+	c.closeReason.CompareAndSwap(nil, &reason)
+	return c.CloseWithError(code, desc)
+}
+
+// classifyDisconnect mapeia o erro retornado pelo quic-go para um DisconnectReason
+func classifyDisconnect(conn *Conn, err error, shuttingDown bool) DisconnectReason {
+	if shuttingDown {
+		return ReasonServerShutdown
+	}
+	if r := conn.closeReason.Load(); r != nil {
+		return *r
+	}
+	var appErr *quic.ApplicationError
+	if errors.As(err, &appErr) {
+		if appErr.Remote {
+			return ReasonClientClosed
+		}
+		return ReasonKicked
+	}
+	var sessErr *webtransport.SessionError
+	if errors.As(err, &sessErr) {
+		if sessErr.Remote {
+			return ReasonClientClosed
+		}
+		return ReasonKicked
+	}
+	var idleErr *quic.IdleTimeoutError
+	if errors.As(err, &idleErr) {
+		return ReasonIdleTimeout
+	}
+	return ReasonError
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDisconnectSummaryWaitsForMessageWorkers(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithMessageWorkers(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	discs := make(chan DisconnectInfo, 1)
+	s.OnMsg = func(_ context.Context, c *Client, _ *Message) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		c.SetID("late")
+	}
+	s.OnDisc = func(_ *Client, info DisconnectInfo) { discs <- info }
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc := connectTestClients(t, ctx, s, 1)[0]
+	if err := tc.Send(&Message{Type: "rename"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-started:
+	case <-ctx.Done():
+		t.Fatal("handler did not run")
+	}
+	tc.Close()
+
+	select {
+	case info := <-discs:
+		if info.Client.ID != "late" {
+			t.Fatalf("summary ID = %q, want the value set by the handler", info.Client.ID)
+		}
+	case <-ctx.Done():
+		t.Fatal("OnDisc was not called")
+	}
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Erros do EntityStore
+var (
+	// ErrEntityExists indica um AddEntity com ID já registrado
+	ErrEntityExists = errors.New("entity already exists")
+	// ErrEntityNotFound indica um UpdateEntity de uma entidade não registrada
+	ErrEntityNotFound = errors.New("entity not found")
+)
+
+// MessageTypeEntitySnapshot é o tipo das mensagens enviadas pelo ReplicateEntities
+const MessageTypeEntitySnapshot = "entity_snapshot"
+
+// Entity é um objeto do mundo replicado para os clients que o veem
+type Entity struct {
+	ID   string `json:"id"`
+	Pos  Point  `json:"pos"`
+	Data any    `json:"data,omitempty"`
+}
+
+// EntitySnapshot é o conteúdo de uma mensagem entity_snapshot: as entidades no
+// raio de visão do client e as que saíram dele desde o snapshot anterior
+type EntitySnapshot struct {
+	Entities []Entity `json:"entities"`
+	Removed  []string `json:"removed,omitempty"`
+}
+
+// EntityStore guarda as entidades do mundo e o índice espacial delas, que é
+// reconstruído no ReplicateEntities seguinte a qualquer alteração
+type EntityStore struct {
+	mu       sync.RWMutex
+	entities map[string]*Entity
+	grid     *spatialGrid[string]
+	dirty    bool
+}
+
+// AddEntity registra uma entidade nova
+func (es *EntityStore) AddEntity(e Entity) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if _, ok := es.entities[e.ID]; ok {
+		return fmt.Errorf("%w: %s", ErrEntityExists, e.ID)
+	}
+	if es.entities == nil {
+		es.entities = make(map[string]*Entity)
+	}
+	es.entities[e.ID] = &e
+	es.dirty = true
+	return nil
+}
+
+// UpdateEntity troca a posição e os dados de uma entidade registrada
+func (es *EntityStore) UpdateEntity(id string, pos Point, data any) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	e, ok := es.entities[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrEntityNotFound, id)
+	}
+	e.Pos, e.Data = pos, data
+	es.dirty = true
+	return nil
+}
+
+// RemoveEntity remove a entidade; os clients que a viam a recebem em Removed
+func (es *EntityStore) RemoveEntity(id string) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if _, ok := es.entities[id]; ok {
+		delete(es.entities, id)
+		es.dirty = true
+	}
+}
+
+// Entity retorna uma cópia da entidade registrada com o ID
+func (es *EntityStore) Entity(id string) (Entity, bool) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	if e, ok := es.entities[id]; ok {
+		return *e, true
+	}
+	return Entity{}, false
+}
+
+// Len retorna o número de entidades registradas
+func (es *EntityStore) Len() int {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	return len(es.entities)
+}
+
+// index reconstrói a grade se houve alterações ou se o tamanho da célula mudou.
+// Deve ser chamado com mu travado.
+func (es *EntityStore) index(cellSize float64) *spatialGrid[string] {
+	if es.grid != nil && !es.dirty && es.grid.cellSize == cellSize {
+		return es.grid
+	}
+	grid := newSpatialGrid[string](cellSize)
+	for id, e := range es.entities {
+		grid.insert(id, e.Pos)
+	}
+	es.grid, es.dirty = grid, false
+	return grid
+}
+
+// entityReplicator lembra quais entidades cada client recebeu no último snapshot
+type entityReplicator struct {
+	mu    sync.Mutex
+	known map[*Conn]map[string]struct{}
+}
+
+// Entities retorna o EntityStore do servidor
+func (s *Server[T, M]) Entities() *EntityStore {
+	return &s.entities
+}
+
+// ReplicateEntities envia a cada client Positioned um entity_snapshot com as
+// entidades a até viewRadius da sua posição. Deve ser chamado a cada tick (ex.:
+// no TickFn). Clients sem entidades à vista e sem remoções não recebem nada.
+// Os snapshots vão por datagrama, já que o do tick seguinte substitui um perdido;
+// os que trazem Removed vão por stream para o client não perder a remoção.
+// A grade usa WithSpatialCellSize, ou viewRadius se ele não for configurado.
+func (s *Server[T, M]) ReplicateEntities(viewRadius float64) error {
+	cellSize := s.opts.spatialCellSize
+	if cellSize <= 0 {
+		cellSize = viewRadius
+	}
+	if cellSize <= 0 {
+		return fmt.Errorf("%w: view radius %v", ErrInvalidOption, viewRadius)
+	}
+
+	type pending struct {
+		conn *Conn
+		id   string
+		snap EntitySnapshot
+	}
+	r := &s.replicator
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.known == nil {
+		r.known = make(map[*Conn]map[string]struct{})
+	}
+
+	var out []pending
+	seen := make(map[*Conn]struct{})
+	s.entities.mu.Lock()
+	grid := s.entities.index(cellSize)
+	s.conns.Range(func(conn *Conn, c T) bool {
+		p, ok := any(c).(Positioned)
+		if !ok {
+			return true
+		}
+		seen[conn] = struct{}{}
+		x, y, z := p.Position()
+		prev := r.known[conn]
+		cur := make(map[string]struct{}, len(prev))
+		var snap EntitySnapshot
+		grid.query(Point{x, y, z}, viewRadius, func(id string, _ Point) {
+			cur[id] = struct{}{}
+			snap.Entities = append(snap.Entities, *s.entities.entities[id])
+		})
+		for id := range prev {
+			if _, ok := cur[id]; !ok {
+				snap.Removed = append(snap.Removed, id)
+			}
+		}
+		r.known[conn] = cur
+		if len(snap.Entities) > 0 || len(snap.Removed) > 0 {
+			out = append(out, pending{conn: conn, id: clientID(conn, c), snap: snap})
+		}
+		return true
+	})
+	s.entities.mu.Unlock()
+
+	// Remove o estado de clients desconectados
+	for conn := range r.known {
+		if _, ok := seen[conn]; !ok {
+			delete(r.known, conn)
+		}
+	}
+
+	var errs []error
+	defer s.orderBroadcasts()()
+	for _, p := range out {
+		if err := s.sendEntitySnapshot(p.conn, p.snap); err != nil {
+			errs = append(errs, fmt.Errorf("client %s: %w", p.id, err))
+			// As remoções voltam a ser enviadas no próximo snapshot
+			for _, id := range p.snap.Removed {
+				r.known[p.conn][id] = struct{}{}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Server[T, M]) sendEntitySnapshot(conn *Conn, snap EntitySnapshot) error {
+	codec := s.opts.codec
+	d, err := codec.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	data, err := codec.Marshal(&Message{Type: MessageTypeEntitySnapshot, Data: d})
+	if err != nil {
+		return err
+	}
+	if len(snap.Removed) > 0 {
+		return conn.sendRaw(data)
+	}
+	return conn.sendUnreliableRaw(data)
+}
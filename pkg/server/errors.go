@@ -0,0 +1,116 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/webtransport-go"
+)
+
+// Erros retornados pelo servidor, para comparação com errors.Is
+var (
+	// ErrMessageTooLarge indica que a mensagem excedeu o tamanho máximo configurado
+	ErrMessageTooLarge = errors.New("message too large")
+	// ErrDatagramTooLarge indica que os dados não cabem em um datagrama da conexão
+	ErrDatagramTooLarge = errors.New("datagram too large")
+	// ErrServerFull indica que o limite de conexões de WithMaxConnections foi atingido
+	ErrServerFull = errors.New("server full")
+	// ErrClientNotFound indica que o client não está conectado ao servidor
+	ErrClientNotFound = errors.New("client not found")
+	// ErrConnectionClosed indica um envio para uma conexão já encerrada
+	ErrConnectionClosed = errors.New("connection closed")
+	// ErrRateLimited indica que o client excedeu o limite de mensagens
+	ErrRateLimited = errors.New("rate limited")
+	// ErrBandwidthExceeded indica que o client excedeu a cota de WithPerClientBandwidthQuota
+	ErrBandwidthExceeded = errors.New("bandwidth quota exceeded")
+	// ErrAuthFailed indica que o handshake de autenticação falhou
+	ErrAuthFailed = errors.New("authentication failed")
+	// ErrBanned indica que o endereço do client está banido
+	ErrBanned = errors.New("address banned")
+	// ErrInvalidOption indica uma configuração inválida passada ao New ou a um setter
+	ErrInvalidOption = errors.New("invalid option")
+)
+
+// closeCodeErrors associa os códigos de fechamento do servidor aos erros exportados
+var closeCodeErrors = map[quic.ApplicationErrorCode]error{
+	CloseCodeServerFull:        ErrServerFull,
+	CloseCodeRateLimited:       ErrRateLimited,
+	CloseCodeAuthFailed:        ErrAuthFailed,
+	CloseCodeBanned:            ErrBanned,
+	CloseCodeSlowClient:        ErrSendQueueFull,
+	CloseCodeBandwidthExceeded: ErrBandwidthExceeded,
+	CloseCodeWriteTimeout:      ErrWriteTimeout,
+	CloseCodeTooManyStreams:    ErrTooManyStreams,
+	CloseCodeHandshakeTimeout:  ErrHandshakeTimeout,
+	CloseCodeRejected:          ErrRejected,
+}
+
+// disconnectErr acrescenta ao erro de uma conexão fechada pelo servidor o erro
+// correspondente ao código usado, para o OnDisc poder usar errors.Is
+func disconnectErr(err error) error {
+	var code quic.ApplicationErrorCode
+	var appErr *quic.ApplicationError
+	var sessErr *webtransport.SessionError
+	switch {
+	case errors.As(err, &appErr) && !appErr.Remote:
+		code = appErr.ErrorCode
+	case errors.As(err, &sessErr) && !sessErr.Remote:
+		code = quic.ApplicationErrorCode(sessErr.ErrorCode)
+	default:
+		return err
+	}
+	if sentinel, ok := closeCodeErrors[code]; ok {
+		return fmt.Errorf("%w: %w", sentinel, err)
+	}
+	return err
+}
+
+// closedErr marca com ErrConnectionClosed os erros de envio numa conexão encerrada
+func (c *Conn) closedErr(err error) error {
+	if err == nil || errors.Is(err, ErrConnectionClosed) || c.Context().Err() == nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrConnectionClosed, err)
+}
+
+// TransportError é o erro entregue ao OnError para falhas de transporte
+type TransportError struct {
+	// Op é a operação que falhou ("accept", "stream accept", "read stream",
+	// "send queue write", "webtransport upgrade", "admin serve")
+	Op string
+	// Addr é o endereço local do listener ou o remoto da conexão, quando conhecido
+	Addr net.Addr
+	// Fatal indica que o listener parou de aceitar conexões; os demais erros
+	// afetam uma única conexão ou requisição e o servidor segue rodando
+	Fatal bool
+	Err   error
+}
+
+func (e *TransportError) Error() string {
+	if e.Fatal {
+		return fmt.Sprintf("%s (fatal): %v", e.Op, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// listenerClosed indica se o erro de accept significa que o listener morreu
+func listenerClosed(err error) bool {
+	return errors.Is(err, quic.ErrServerClosed) || errors.Is(err, quic.ErrTransportClosed) || errors.Is(err, net.ErrClosed)
+}
+
+// reportError loga a falha de transporte e a entrega ao OnError
+func (s *Server[T, M]) reportError(op string, addr net.Addr, fatal bool, err error) {
+	terr := &TransportError{Op: op, Addr: addr, Fatal: fatal, Err: err}
+	log.Println(terr.Error())
+	if s.OnError != nil {
+		s.OnError(terr)
+	}
+	s.events.emit(Event{Kind: EventError, Err: terr})
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultEventBuffer é o tamanho padrão do canal do Events
+const DefaultEventBuffer = 256
+
+// EventKind identifica o tipo de um Event
+type EventKind int
+
+const (
+	EventConnect EventKind = iota
+	EventReconnect
+	EventDisconnect
+	EventRoomChange
+	EventKick
+	EventError
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventConnect:
+		return "connect"
+	case EventReconnect:
+		return "reconnect"
+	case EventDisconnect:
+		return "disconnect"
+	case EventRoomChange:
+		return "room_change"
+	case EventKick:
+		return "kick"
+	case EventError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Event é um evento do ciclo de vida do servidor entregue pelo Events
+type Event struct {
+	Kind EventKind
+	Time time.Time
+	// Client é o retrato do client; vazio em EventError
+	Client PresenceEntry
+	// PrevRoom é a sala anterior em EventRoomChange (a nova fica em Client.Room)
+	PrevRoom string
+	// Reason é o motivo em EventDisconnect e EventKick
+	Reason DisconnectReason
+	// Err é o erro em EventDisconnect, EventKick e EventError (*TransportError)
+	Err error
+}
+
+// EventDropPolicy define o que acontece quando o canal do Events está cheio
+type EventDropPolicy int
+
+const (
+	// EventDropOldest descarta o evento mais antigo do canal para abrir espaço
+	EventDropOldest EventDropPolicy = iota
+	// EventBlock espera o consumidor ler, segurando quem gerou o evento
+	EventBlock
+)
+
+// WithEventBuffer define o tamanho do canal do Events e o que fazer quando ele
+// enche (padrão: DefaultEventBuffer e EventDropOldest). Com EventBlock um
+// consumidor lento atrasa conexões, desconexões e handlers.
+func WithEventBuffer(size int, policy EventDropPolicy) Option {
+	return func(o *options) {
+		o.eventBuffer = size
+		o.eventPolicy = policy
+	}
+}
+
+// eventBus é o canal do Events, criado na primeira chamada
+type eventBus struct {
+	once    sync.Once
+	enabled atomic.Bool
+	policy  EventDropPolicy
+	dropped atomic.Int64
+
+	mu     sync.RWMutex
+	ch     chan Event
+	done   chan struct{}
+	closed bool
+}
+
+// Events retorna um canal com os eventos de conexão, reconexão, desconexão,
+// troca de sala, kick e erro, complementando os callbacks. Os eventos só são
+// gerados depois da primeira chamada; todas retornam o mesmo canal, que é
+// fechado pelo Stop. A troca de sala é detectada depois de cada mensagem
+// processada (clients que implementam RoomMember), e um kick gera EventKick
+// seguido de EventDisconnect.
+func (s *Server[T, M]) Events() <-chan Event {
+	b := &s.events
+	b.once.Do(func() {
+		size := s.opts.eventBuffer
+		if size <= 0 {
+			size = DefaultEventBuffer
+		}
+		b.ch = make(chan Event, size)
+		b.done = make(chan struct{})
+		b.policy = s.opts.eventPolicy
+		b.enabled.Store(true)
+	})
+	return b.ch
+}
+
+func (b *eventBus) emit(ev Event) {
+	if !b.enabled.Load() {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	ev.Time = time.Now()
+	if b.policy == EventBlock {
+		select {
+		case b.ch <- ev:
+		case <-b.done:
+		}
+		return
+	}
+	for {
+		select {
+		case b.ch <- ev:
+			return
+		default:
+		}
+		select {
+		case <-b.ch:
+			b.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// close fecha o canal; emissões bloqueadas são liberadas antes
+func (b *eventBus) close() {
+	if !b.enabled.Load() {
+		return
+	}
+	b.mu.RLock()
+	closed := b.closed
+	b.mu.RUnlock()
+	if closed {
+		return
+	}
+	close(b.done)
+	b.mu.Lock()
+	b.closed = true
+	close(b.ch)
+	b.mu.Unlock()
+}
+
+// emitClient gera um evento com o retrato do client
+func (s *Server[T, M]) emitClient(kind EventKind, conn *Conn, c T, info DisconnectInfo) {
+	if !s.events.enabled.Load() {
+		return
+	}
+	entry := info.Client
+	if entry.ID == "" {
+		entry = presenceEntry(conn, c)
+	}
+	s.events.emit(Event{Kind: kind, Client: entry, Reason: info.Reason, Err: info.Err})
+}
+
+// trackRoom guarda a sala atual do client e gera EventRoomChange se ela mudou
+func (s *Server[T, M]) trackRoom(conn *Conn, c T) {
+	if !s.events.enabled.Load() {
+		return
+	}
+	r, ok := any(c).(RoomMember)
+	if !ok {
+		return
+	}
+	room := r.GetRoom()
+	prev := conn.room.Swap(&room)
+	if prev == nil || *prev == room {
+		return
+	}
+	s.events.emit(Event{Kind: EventRoomChange, Client: presenceEntry(conn, c), PrevRoom: *prev})
+}
@@ -0,0 +1,312 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/webtransport-go"
+)
+
+// ErrFileCanceled indica uma transferência de arquivo cancelada pelo ctx ou pelo outro lado
+var ErrFileCanceled = errors.New("file transfer canceled")
+
+// DefaultFileChunkSize é o tamanho dos trechos lidos e escritos pelo SendFile
+const DefaultFileChunkSize = 64 * 1024
+
+// FileHeader abre a stream unidirecional de um arquivo, num frame com prefixo de
+// comprimento; o conteúdo vem em seguida, até o fim da stream
+type FileHeader struct {
+	Name string `json:"name"`
+	// Size é o tamanho do conteúdo, ou -1 se não for conhecido
+	Size int64 `json:"size"`
+}
+
+// uniSendStream é uma stream unidirecional de saída QUIC ou WebTransport
+type uniSendStream struct {
+	q  *quic.SendStream
+	wt *webtransport.SendStream
+}
+
+func (s *uniSendStream) Write(p []byte) (int, error) {
+	if s.wt != nil {
+		return s.wt.Write(p)
+	}
+	return s.q.Write(p)
+}
+
+func (s *uniSendStream) Close() error {
+	if s.wt != nil {
+		return s.wt.Close()
+	}
+	return s.q.Close()
+}
+
+func (s *uniSendStream) CancelWrite(code quic.StreamErrorCode) {
+	if s.wt != nil {
+		s.wt.CancelWrite(webtransport.StreamErrorCode(code))
+		return
+	}
+	s.q.CancelWrite(code)
+}
+
+func (s *uniSendStream) SetWriteDeadline(t time.Time) error {
+	if s.wt != nil {
+		return s.wt.SetWriteDeadline(t)
+	}
+	return s.q.SetWriteDeadline(t)
+}
+
+// uniReceiveStream é uma stream unidirecional de entrada QUIC ou WebTransport
+type uniReceiveStream struct {
+	q  *quic.ReceiveStream
+	wt *webtransport.ReceiveStream
+}
+
+func (s *uniReceiveStream) Read(p []byte) (int, error) {
+	if s.wt != nil {
+		return s.wt.Read(p)
+	}
+	return s.q.Read(p)
+}
+
+func (s *uniReceiveStream) CancelRead(code quic.StreamErrorCode) {
+	if s.wt != nil {
+		s.wt.CancelRead(webtransport.StreamErrorCode(code))
+		return
+	}
+	s.q.CancelRead(code)
+}
+
+func (s *uniReceiveStream) SetReadDeadline(t time.Time) error {
+	if s.wt != nil {
+		return s.wt.SetReadDeadline(t)
+	}
+	return s.q.SetReadDeadline(t)
+}
+
+func (c *Conn) openUniStream(ctx context.Context) (*uniSendStream, error) {
+	if c.detached != nil {
+		return nil, errDetachedConn
+	}
+	if c.session != nil {
+		str, err := c.session.OpenUniStreamSync(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &uniSendStream{wt: str}, nil
+	}
+	str, err := c.Conn.OpenUniStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &uniSendStream{q: str}, nil
+}
+
+func (c *Conn) acceptUniStream(ctx context.Context) (*uniReceiveStream, error) {
+	if c.detached != nil {
+		return nil, errDetachedConn
+	}
+	if c.session != nil {
+		str, err := c.session.AcceptUniStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &uniReceiveStream{wt: str}, nil
+	}
+	str, err := c.Conn.AcceptUniStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &uniReceiveStream{q: str}, nil
+}
+
+// SendFile envia o conteúdo de r ao client numa stream unidirecional própria,
+// em trechos de DefaultFileChunkSize, sem carregar o arquivo inteiro na memória.
+// O client recebe um FileHeader e depois o conteúdo até o fim da stream.
+func (s *Server[T, M]) SendFile(c T, name string, r io.Reader) error {
+	return s.SendFileContext(context.Background(), c, name, r)
+}
+
+// SendFileContext é o SendFile com cancelamento: ao fim do ctx a stream é
+// cancelada com StreamCodeFileCanceled e o erro inclui ErrFileCanceled, o mesmo
+// retornado quando o client desiste do arquivo. O progresso vai para OnFileProgress.
+func (s *Server[T, M]) SendFileContext(ctx context.Context, c T, name string, r io.Reader) error {
+	conn, err := s.connOf(c)
+	if err != nil {
+		return err
+	}
+	total := readerSize(r)
+	var progress func(sent int64)
+	if s.OnFileProgress != nil {
+		progress = func(sent int64) { s.OnFileProgress(c, name, sent, total) }
+	}
+	return conn.sendFile(ctx, s.opts.codec, FileHeader{Name: name, Size: total}, r, progress)
+}
+
+func (c *Conn) sendFile(ctx context.Context, codec Codec, hdr FileHeader, r io.Reader, progress func(sent int64)) error {
+	header, err := codec.Marshal(hdr)
+	if err != nil {
+		return fmt.Errorf("marshal file header: %w", err)
+	}
+	str, err := c.openUniStream(ctx)
+	if err != nil {
+		return fileErr(ctx, c.closedErr(err))
+	}
+	stop := context.AfterFunc(ctx, func() { str.CancelWrite(StreamCodeFileCanceled) })
+	defer stop()
+
+	write := func(data []byte) error {
+		byTimeout := c.setWriteDeadline(str, time.Time{})
+		if _, err := str.Write(data); err != nil {
+			str.CancelWrite(StreamCodeFileCanceled)
+			return fileErr(ctx, c.closedErr(c.writeTimeoutErr(err, byTimeout)))
+		}
+		c.bytesOut.Add(int64(len(data)))
+		return nil
+	}
+	// O FileHeader vai sempre num frame, com ou sem WithFraming
+	frame := getBuffer()
+	defer releaseBuffer(frame)
+	writeFrame(frame, header)
+	if err := write(frame.Bytes()); err != nil {
+		return err
+	}
+	buf := make([]byte, DefaultFileChunkSize)
+	var sent int64
+	for {
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			if err := write(buf[:n]); err != nil {
+				return err
+			}
+			sent += int64(n)
+			if progress != nil {
+				progress(sent)
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			str.CancelWrite(StreamCodeFileCanceled)
+			return fmt.Errorf("read file: %w", rerr)
+		}
+	}
+	if err := str.Close(); err != nil {
+		return fileErr(ctx, err)
+	}
+	return nil
+}
+
+// fileErr marca com ErrFileCanceled os erros causados pelo ctx ou pelo outro lado
+func fileErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", ErrFileCanceled, context.Cause(ctx))
+	}
+	var streamErr *quic.StreamError
+	if errors.As(err, &streamErr) && streamErr.ErrorCode == StreamCodeFileCanceled {
+		return fmt.Errorf("%w: %w", ErrFileCanceled, err)
+	}
+	var wtErr *webtransport.StreamError
+	if errors.As(err, &wtErr) && wtErr.ErrorCode == webtransport.StreamErrorCode(StreamCodeFileCanceled) {
+		return fmt.Errorf("%w: %w", ErrFileCanceled, err)
+	}
+	return err
+}
+
+// readerSize retorna o tamanho restante de r quando ele é conhecido, ou -1
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		pos, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - pos
+	}
+	return -1
+}
+
+// fileLoop aceita as streams unidirecionais de arquivos enviadas pelo client
+func (s *Server[T, M]) fileLoop(ctx context.Context, conn *Conn, c T) {
+	defer s.wg.Done()
+	for {
+		str, err := conn.acceptUniStream(ctx)
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		conn.active.Add(1)
+		go s.handleFile(ctx, conn, c, str)
+	}
+}
+
+// handleFile lê o FileHeader e entrega o conteúdo ao OnFile. Se o OnFile
+// retornar antes do fim, a stream é cancelada e o client recebe ErrFileCanceled.
+func (s *Server[T, M]) handleFile(ctx context.Context, conn *Conn, c T, str *uniReceiveStream) {
+	defer s.wg.Done()
+	defer conn.active.Done()
+	defer unblockReads(ctx, str)()
+	hdr, err := readFileHeader(str, s.opts.codec, s.opts.maxMessageSize, s.opts.readTimeout)
+	if err != nil {
+		str.CancelRead(StreamCodeFileCanceled)
+		if conn.Context().Err() == nil {
+			s.reportError("read file header", conn.RemoteAddr(), false, err)
+		}
+		return
+	}
+	conn.markFirstMessage()
+	if !awaitReady(ctx, conn) {
+		str.CancelRead(StreamCodeFileCanceled)
+		return
+	}
+	body := &fileReader{r: str, conn: conn}
+	s.OnFile(c, hdr.Name, body)
+	if !body.eof {
+		str.CancelRead(StreamCodeFileCanceled)
+	}
+}
+
+// readFileHeader lê o frame do FileHeader respeitando o WithReadTimeout
+func readFileHeader(str *uniReceiveStream, codec Codec, limit int, timeout time.Duration) (FileHeader, error) {
+	if timeout > 0 {
+		str.SetReadDeadline(time.Now().Add(timeout))
+		defer str.SetReadDeadline(time.Time{})
+	}
+	var hdr FileHeader
+	data, err := readFrame(str, limit)
+	if err != nil {
+		return hdr, err
+	}
+	if err := codec.Unmarshal(data, &hdr); err != nil {
+		return hdr, fmt.Errorf("decode file header: %w", err)
+	}
+	return hdr, nil
+}
+
+// fileReader conta os bytes recebidos e lembra se a stream chegou ao fim
+type fileReader struct {
+	r    io.Reader
+	conn *Conn
+	eof  bool
+}
+
+func (f *fileReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	f.conn.bytesIn.Add(int64(n))
+	if err == io.EOF {
+		f.eof = true
+	}
+	return n, err
+}
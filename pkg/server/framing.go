@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/binary"
+	"io"
+	"slices"
+)
+
+// frameHeaderSize é o tamanho do prefixo de comprimento de cada frame
+const frameHeaderSize = 4
+
+// frameTrustedSize é o maior frame alocado de uma vez pelo prefixo; acima disso
+// o buffer cresce conforme os bytes chegam, para um prefixo mentiroso não
+// reservar memória que o peer nunca enviou
+const frameTrustedSize = 64 << 10
+
+// WriteFrame escreve data no formato do WithFraming, para clients em Go
+func WriteFrame(w io.Writer, data []byte) error {
+	return writeFrame(w, data)
+}
+
+// ReadFrame lê um frame no formato do WithFraming, descomprimindo os frames
+// comprimidos pelo servidor (limit 0 = sem limite)
+func ReadFrame(r io.Reader, limit int) ([]byte, error) {
+	return readFrame(r, limit)
+}
+
+// writeFrame escreve data precedido pelo seu comprimento (uint32 big-endian).
+// Cabeçalho e corpo vão numa única escrita, para não gerar um pacote só com o prefixo.
+func writeFrame(w io.Writer, data []byte) error {
+	return writeFrameHeader(w, uint32(len(data)), data)
+}
+
+func writeFrameHeader(w io.Writer, header uint32, data []byte) error {
+	buf := getBuffer()
+	defer releaseBuffer(buf)
+	var hdr [frameHeaderSize]byte
+	binary.BigEndian.PutUint32(hdr[:], header)
+	buf.Write(hdr[:])
+	buf.Write(data)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readFrame lê um frame com prefixo de comprimento, descomprimindo-o se
+// necessário. Retorna io.EOF se a stream terminou antes de um novo frame e
+// ErrMessageTooLarge se o frame excede limit.
+func readFrame(r io.Reader, limit int) ([]byte, error) {
+	var hdr [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[:])
+	compressed := n&frameCompressedFlag != 0
+	n &^= frameCompressedFlag
+	if limit > 0 && int64(n) > int64(limit) {
+		return nil, ErrMessageTooLarge
+	}
+	data, err := readFrameBody(r, int(n))
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if compressed {
+		return decompressFrame(data, limit)
+	}
+	return data, nil
+}
+
+// readFrameBody lê exatamente n bytes. Até frameTrustedSize a alocação é única;
+// frames maiores dobram o buffer a cada trecho lido, ficando no máximo com o
+// dobro do que realmente chegou.
+func readFrameBody(r io.Reader, n int) ([]byte, error) {
+	if n <= frameTrustedSize {
+		data := make([]byte, n)
+		_, err := io.ReadFull(r, data)
+		return data, err
+	}
+	data := make([]byte, 0, frameTrustedSize)
+	for len(data) < n {
+		if len(data) == cap(data) {
+			data = slices.Grow(data, min(cap(data), n-len(data)))
+		}
+		chunk := data[len(data):min(cap(data), n)]
+		read, err := io.ReadFull(r, chunk)
+		data = data[:len(data)+read]
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+func TestReadFrameRejectsOversizedPrefix(t *testing.T) {
+	var hdr [frameHeaderSize]byte
+	binary.BigEndian.PutUint32(hdr[:], 1<<30)
+	r := bytes.NewReader(hdr[:])
+	// Só o prefixo é enviado: ler o corpo daria ErrUnexpectedEOF
+	if _, err := readFrame(r, 1024); !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("readFrame = %v, want ErrMessageTooLarge", err)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(hdr[:])
+		readFrame(r, 1024)
+	})
+	// No máximo o cabeçalho de 4 bytes, nunca o corpo anunciado
+	if allocs > 1 {
+		t.Fatalf("readFrame allocated %v times for a rejected prefix", allocs)
+	}
+}
+
+func TestReadMessageRejectsOversized(t *testing.T) {
+	s := &Server[*Client, *Message]{opts: options{maxMessageSize: 16}}
+	if _, err := s.readMessage(strings.NewReader(strings.Repeat("x", 17))); !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("readMessage = %v, want ErrMessageTooLarge", err)
+	}
+	data, err := s.readMessage(strings.NewReader(strings.Repeat("x", 16)))
+	if err != nil || len(data) != 16 {
+		t.Fatalf("readMessage at the limit = %d bytes, %v", len(data), err)
+	}
+}
+
+func TestUnframedOversizedMessageIsRejected(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithMaxMessageSize(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.OnMsg = func(context.Context, *Client, *Message) { t.Error("oversized message was delivered") }
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc := connectTestClients(t, ctx, s, 1)[0]
+
+	str, err := tc.Conn.OpenStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	str.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	// Maior que a janela de controle de fluxo: a escrita só termina se o servidor ler
+	_, err = str.Write(bytes.Repeat([]byte("x"), 4<<20))
+	var streamErr *quic.StreamError
+	if !errors.As(err, &streamErr) || streamErr.ErrorCode != StreamCodeMessageTooLarge {
+		t.Fatalf("write = %v, want the stream canceled with StreamCodeMessageTooLarge", err)
+	}
+}
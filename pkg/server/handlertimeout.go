@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithHandlerTimeout executa cada handler de mensagem com um contexto com prazo d.
+// Se o prazo estourar o OnHandlerTimeout é chamado e a stream segue para a próxima
+// mensagem; o handler continua rodando até retornar, então deve respeitar o ctx.
+// O OnDisc e o Stop esperam os handlers atrasados terminarem.
+func WithHandlerTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.handlerTimeout = d
+	}
+}
+
+// WithHandlerCircuitBreaker descarta as mensagens de um client por cooldown após
+// threshold timeouts seguidos de handler. Requer WithHandlerTimeout.
+func WithHandlerCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(o *options) {
+		o.breakerThreshold = threshold
+		o.breakerCooldown = cooldown
+	}
+}
+
+// circuitBreaker conta timeouts seguidos de um client
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// allow indica se o circuito está fechado e a mensagem pode ser processada
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !now.Before(b.openUntil)
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	b.failures = 0
+	b.mu.Unlock()
+}
+
+// failure registra um timeout e abre o circuito ao atingir o limite
+func (b *circuitBreaker) failure(threshold int, cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures >= threshold {
+		b.failures = 0
+		b.openUntil = time.Now().Add(cooldown)
+	}
+}
+
+// runHandler despacha a mensagem respeitando o timeout de handler e o circuit breaker
+func (s *Server[T, M]) runHandler(ctx context.Context, conn *Conn, c T, msgType string, msg M) {
+	if s.opts.handlerTimeout <= 0 {
+		s.dispatch(ctx, conn, c, msgType, msg)
+		return
+	}
+	if conn.breaker != nil && !conn.breaker.allow(time.Now()) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.opts.handlerTimeout)
+	defer cancel()
+	done := make(chan struct{})
+	// O handler pode passar do prazo: a conexão e o servidor esperam por ele
+	// antes do OnDisc e do fim do Stop
+	s.wg.Add(1)
+	conn.active.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer conn.active.Done()
+		defer close(done)
+		s.route(ctx, conn, c, msgType, msg)
+	}()
+
+	select {
+	case <-done:
+		s.afterDispatch(conn, c)
+		if conn.breaker != nil {
+			conn.breaker.success()
+		}
+	case <-ctx.Done():
+		if ctx.Err() != context.DeadlineExceeded {
+			return
+		}
+		trace.SpanFromContext(ctx).SetStatus(codes.Error, "handler timeout")
+		if s.OnHandlerTimeout != nil {
+			s.OnHandlerTimeout(c, msg)
+		}
+		if conn.breaker != nil {
+			conn.breaker.failure(s.opts.breakerThreshold, s.opts.breakerCooldown)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHandlerTimeoutDisconnectWaitsForHandler(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithHandlerTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	returned := make(chan struct{})
+	timedOut := make(chan struct{}, 1)
+	discs := make(chan struct{}, 1)
+	s.Handle("slow", func(_ context.Context, _ *Client, _ *Message) {
+		<-release
+		close(returned)
+	})
+	s.OnHandlerTimeout = func(*Client, *Message) { timedOut <- struct{}{} }
+	s.OnDisc = func(*Client, DisconnectInfo) {
+		select {
+		case <-returned:
+		default:
+			t.Error("OnDisc ran while the timed-out handler was still running")
+		}
+		discs <- struct{}{}
+	}
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc, err := NewTestClient(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.Send(&Message{Type: "slow"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-timedOut:
+	case <-ctx.Done():
+		t.Fatal("OnHandlerTimeout was not called")
+	}
+
+	tc.Close()
+	select {
+	case <-discs:
+		t.Fatal("OnDisc before the handler returned")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-discs:
+	case <-ctx.Done():
+		t.Fatal("OnDisc was not called")
+	}
+}
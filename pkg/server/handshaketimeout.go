@@ -0,0 +1,50 @@
+package server
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrHandshakeTimeout indica um client que não enviou a primeira mensagem no prazo de WithHandshakeTimeout
+var ErrHandshakeTimeout = errors.New("handshake timeout")
+
+// WithHandshakeTimeout limita o handshake do QUIC (HandshakeIdleTimeout) e o
+// tempo entre a conexão ser aceita e a primeira mensagem válida do client
+// (0 = padrão do QUIC e sem limite para a primeira mensagem). Contam como
+// primeira mensagem as de auth, versão e sessão, qualquer mensagem decodificada,
+// um datagrama e, com OnStream, uma stream aceita. Clients que não a enviam a
+// tempo são desconectados com CloseCodeHandshakeTimeout.
+func WithHandshakeTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.handshakeTimeout = d
+	}
+}
+
+// applyHandshakeTimeout ajusta o HandshakeIdleTimeout do QUIC
+func (o *options) applyHandshakeTimeout() {
+	if o.handshakeTimeout > 0 {
+		o.quicConfig.HandshakeIdleTimeout = o.handshakeTimeout
+	}
+}
+
+// awaitFirstMessage desconecta o client se a primeira mensagem não chegar no prazo
+func (s *Server[T, M]) awaitFirstMessage(conn *Conn) {
+	if s.opts.handshakeTimeout <= 0 {
+		return
+	}
+	conn.firstMsg = time.AfterFunc(s.opts.handshakeTimeout, func() {
+		if !conn.gotFirstMsg.Load() && conn.Context().Err() == nil {
+			conn.closeWithReason(ReasonKicked, CloseCodeHandshakeTimeout, ErrHandshakeTimeout.Error())
+		}
+	})
+}
+
+// markFirstMessage registra que o client enviou uma mensagem válida
+func (c *Conn) markFirstMessage() {
+	if c.firstMsg == nil || c.gotFirstMsg.Load() {
+		return
+	}
+	if c.gotFirstMsg.CompareAndSwap(false, true) {
+		c.firstMsg.Stop()
+	}
+}
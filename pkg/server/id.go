@@ -0,0 +1,31 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// WithIDGenerator define a função que gera o ID de cada client novo (padrão: RandomID)
+func WithIDGenerator(gen func() string) Option {
+	return func(o *options) {
+		if gen != nil {
+			o.idGenerator = gen
+		}
+	}
+}
+
+// RandomID gera um ID aleatório de 128 bits em hexadecimal
+func RandomID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// assignID define o ID do client antes do OnConn, se o ClientFactory não definiu um
+func assignID(client any, gen func() string) {
+	c, ok := client.(ClientInterface)
+	if !ok || c.GetID() != "" {
+		return
+	}
+	c.SetID(gen())
+}
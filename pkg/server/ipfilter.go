@@ -0,0 +1,60 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net"
+)
+
+var (
+	// errAddrDenied recusa endereços em uma faixa negada ou fora das faixas permitidas
+	errAddrDenied = errors.New("address not allowed")
+)
+
+// WithAllowedCIDRs aceita conexões apenas das faixas informadas
+func WithAllowedCIDRs(nets []*net.IPNet) Option {
+	return func(o *options) {
+		o.allowedCIDRs = nets
+	}
+}
+
+// WithDeniedCIDRs recusa conexões das faixas informadas. Tem precedência sobre WithAllowedCIDRs.
+func WithDeniedCIDRs(nets []*net.IPNet) Option {
+	return func(o *options) {
+		o.deniedCIDRs = nets
+	}
+}
+
+// admit aplica a política de acesso em camadas: faixas negadas, faixas
+// permitidas e por fim a lista de bans
+func (o options) admit(addr net.Addr) error {
+	ip := addrIP(addr)
+	if ip != nil {
+		if containsIP(o.deniedCIDRs, ip) {
+			return errAddrDenied
+		}
+		if len(o.allowedCIDRs) > 0 && !containsIP(o.allowedCIDRs, ip) {
+			return errAddrDenied
+		}
+	} else if len(o.allowedCIDRs) > 0 {
+		return errAddrDenied
+	}
+	if o.bans.isBanned(addr) {
+		return ErrBanned
+	}
+	return nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// logRejected registra uma conexão recusada pela política de acesso
+func logRejected(addr net.Addr, err error) {
+	log.Printf("warning: rejected connection from %s: %v\n", addr, err)
+}
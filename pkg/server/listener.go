@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log"
+	"net"
+
+	"github.com/quic-go/quic-go"
+)
+
+// listener agrupa um transport QUIC e o listener criado sobre ele
+type listener struct {
+	tr *quic.Transport
+	ln *quic.Listener
+	// external indica que o transport pertence ao usuário e não deve ser fechado
+	external bool
+}
+
+func (l *listener) close() error {
+	if l.external {
+		return l.ln.Close()
+	}
+	return errors.Join(l.ln.Close(), l.tr.Close())
+}
+
+// listen abre os sockets UDP configurados em addr e cria um listener QUIC para cada um
+func listen(addr string, tlsConf *tls.Config, o options) ([]*listener, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	n := 1
+	if o.reusePort > 1 {
+		if reusePortSupported {
+			n = o.reusePort
+		} else {
+			log.Println("SO_REUSEPORT not supported on this platform, using a single socket")
+		}
+	}
+
+	var lns []*listener
+	for i := 0; i < n; i++ {
+		var pc net.PacketConn
+		if n > 1 {
+			pc, err = listenReusePort(udpAddr)
+		} else {
+			pc, err = net.ListenUDP("udp", udpAddr)
+		}
+		if err != nil {
+			closeListeners(lns)
+			return nil, err
+		}
+		if o.udpBufferSize > 0 {
+			setUDPBufferSize(pc, o.udpBufferSize)
+		}
+		// Com porta 0 os próximos sockets precisam usar a porta escolhida pelo primeiro
+		udpAddr = pc.LocalAddr().(*net.UDPAddr)
+
+		l, err := newListener(pc, tlsConf, o)
+		if err != nil {
+			pc.Close()
+			closeListeners(lns)
+			return nil, err
+		}
+		lns = append(lns, l)
+	}
+	return lns, nil
+}
+
+// setUDPBufferSize ajusta os buffers do socket e avisa se o SO não honrar o tamanho pedido
+func setUDPBufferSize(pc net.PacketConn, size int) {
+	conn, ok := pc.(*net.UDPConn)
+	if !ok {
+		return
+	}
+	if err := conn.SetReadBuffer(size); err != nil {
+		log.Println("set UDP read buffer error:", err)
+	}
+	if err := conn.SetWriteBuffer(size); err != nil {
+		log.Println("set UDP write buffer error:", err)
+	}
+	read, write, err := socketBufferSizes(conn)
+	if err != nil {
+		return
+	}
+	log.Printf("UDP buffer sizes: read=%d write=%d (requested %d)\n", read, write, size)
+	if read < size || write < size {
+		log.Printf("warning: OS did not honor requested UDP buffer size %d; raise net.core.rmem_max/wmem_max\n", size)
+	}
+}
+
+func newListener(pc net.PacketConn, tlsConf *tls.Config, o options) (*listener, error) {
+	tr := &quic.Transport{
+		Conn:                pc,
+		VerifySourceAddress: o.sourceAddrVerifier(),
+	}
+	installConnContext(tr, o)
+	ln, err := tr.Listen(tlsConf, o.quicConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &listener{tr: tr, ln: ln}, nil
+}
+
+// installConnContext faz o transport recusar endereços barrados pela política de
+// acesso antes do handshake e anexar um connMetrics ao contexto de cada conexão aceita
+func installConnContext(tr *quic.Transport, o options) {
+	prev := tr.ConnContext
+	tr.ConnContext = func(ctx context.Context, info *quic.ClientInfo) (context.Context, error) {
+		if err := o.admit(info.RemoteAddr); err != nil {
+			logRejected(info.RemoteAddr, err)
+			return nil, err
+		}
+		if prev != nil {
+			var err error
+			if ctx, err = prev(ctx, info); err != nil {
+				return nil, err
+			}
+		}
+		return context.WithValue(ctx, connMetricsKey{}, &connMetrics{}), nil
+	}
+}
+
+func closeListeners(lns []*listener) {
+	for _, l := range lns {
+		l.close()
+	}
+}
+
+// WithReusePort abre n sockets UDP com SO_REUSEPORT no mesmo endereço para
+// distribuir o processamento de pacotes entre núcleos. Em plataformas sem
+// suporte um único socket é usado.
+func WithReusePort(n int) Option {
+	return func(o *options) {
+		o.reusePort = n
+	}
+}
+
+// WithUDPBufferSize define o tamanho dos buffers de leitura e escrita dos sockets UDP.
+// O GSO (generic segmentation offload) do quic-go não é configurável por
+// servidor: para desabilitá-lo defina QUIC_GO_DISABLE_GSO=true no ambiente do processo.
+func WithUDPBufferSize(bytes int) Option {
+	return func(o *options) {
+		o.udpBufferSize = bytes
+	}
+}
@@ -0,0 +1,329 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// memAddr é o endereço de um memPacketConn
+type memAddr string
+
+func (a memAddr) Network() string { return "mem" }
+func (a memAddr) String() string  { return string(a) }
+
+type memPacket struct {
+	data []byte
+	from net.Addr
+}
+
+// memNetwork conecta memPacketConns entregando pacotes por canais, sem rede
+type memNetwork struct {
+	mu    sync.Mutex
+	conns map[memAddr]*memPacketConn
+	next  atomic.Uint64
+}
+
+func newMemNetwork() *memNetwork {
+	return &memNetwork{conns: make(map[memAddr]*memPacketConn)}
+}
+
+func (n *memNetwork) listen() *memPacketConn {
+	addr := memAddr(fmt.Sprintf("mem-%d", n.next.Add(1)))
+	c := &memPacketConn{
+		addr:     addr,
+		network:  n,
+		in:       make(chan memPacket, 1024),
+		closed:   make(chan struct{}),
+		deadline: make(chan struct{}),
+	}
+	n.mu.Lock()
+	n.conns[addr] = c
+	n.mu.Unlock()
+	return c
+}
+
+// memPacketConn é um net.PacketConn em memória usado pelo servidor de teste
+type memPacketConn struct {
+	addr    memAddr
+	network *memNetwork
+	in      chan memPacket
+
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	deadline chan struct{}
+}
+
+func (c *memPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	c.mu.Lock()
+	deadline := c.deadline
+	c.mu.Unlock()
+	select {
+	case pkt := <-c.in:
+		return copy(p, pkt.data), pkt.from, nil
+	case <-c.closed:
+		return 0, nil, net.ErrClosed
+	case <-deadline:
+		return 0, nil, os.ErrDeadlineExceeded
+	}
+}
+
+func (c *memPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	c.network.mu.Lock()
+	dst, ok := c.network.conns[memAddr(addr.String())]
+	c.network.mu.Unlock()
+	if !ok {
+		// Como no UDP, pacotes para destinos inexistentes são descartados
+		return len(p), nil
+	}
+	data := make([]byte, len(p))
+	copy(data, p)
+	select {
+	case dst.in <- memPacket{data: data, from: c.addr}:
+	default:
+		// Fila cheia: descarta, como um socket UDP sobrecarregado
+	}
+	return len(p), nil
+}
+
+func (c *memPacketConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.network.mu.Lock()
+		delete(c.network.conns, c.addr)
+		c.network.mu.Unlock()
+	})
+	return nil
+}
+
+func (c *memPacketConn) LocalAddr() net.Addr { return c.addr }
+
+func (c *memPacketConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+func (c *memPacketConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	// Um novo canal garante que um deadline expirado anteriormente não afete leituras futuras
+	select {
+	case <-c.deadline:
+		c.deadline = make(chan struct{})
+	default:
+	}
+	if t.IsZero() {
+		return nil
+	}
+	ch := c.deadline
+	d := time.Until(t)
+	if d <= 0 {
+		close(ch)
+		return nil
+	}
+	c.timer = time.AfterFunc(d, func() { close(ch) })
+	return nil
+}
+
+func (c *memPacketConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// NewTestServer cria um servidor sobre uma rede em memória, sem abrir portas UDP.
+// Use NewTestClient para conectar clients a ele em testes determinísticos.
+func NewTestServer[T, M any](clientFactory ClientFactory[T], messageFactory MessageFactory[M], opts ...Option) (*Server[T, M], error) {
+	pc := newMemNetwork().listen()
+	s, err := NewWithTransport(&quic.Transport{Conn: pc}, clientFactory, messageFactory, opts...)
+	if err != nil {
+		pc.Close()
+		return nil, err
+	}
+	// O transport em memória pertence ao servidor de teste
+	s.listeners[0].external = false
+	return s, nil
+}
+
+// TestClient é um client QUIC conectado a um servidor de teste pela rede em memória
+type TestClient struct {
+	Conn *quic.Conn
+
+	tr     *quic.Transport
+	codec  Codec
+	framed bool
+	msgs   chan *Message
+
+	mu  sync.Mutex
+	out *quic.Stream
+}
+
+// testClientMaxStreams permite ao servidor abrir muitas streams no TestClient,
+// como nos envios sem framing, que usam uma stream por mensagem
+const testClientMaxStreams = 10000
+
+// NewTestClient conecta um client ao servidor criado com NewTestServer
+func NewTestClient[T, M any](ctx context.Context, s *Server[T, M]) (*TestClient, error) {
+	srv, ok := s.listeners[0].tr.Conn.(*memPacketConn)
+	if !ok {
+		return nil, errors.New("server was not created with NewTestServer")
+	}
+	pc := srv.network.listen()
+	tr := &quic.Transport{Conn: pc}
+	protos := s.opts.alpnProtocols()
+	if s.opts.compression != CompressionNone {
+		// O TestClient descomprime os frames, então aceita a compressão
+		protos = compressionALPN(protos)
+	}
+	tlsConf := &tls.Config{InsecureSkipVerify: true, NextProtos: protos}
+	conn, err := tr.Dial(ctx, srv.addr, tlsConf, &quic.Config{EnableDatagrams: true, MaxIncomingStreams: testClientMaxStreams})
+	if err != nil {
+		tr.Close()
+		return nil, err
+	}
+	c := &TestClient{
+		Conn:   conn,
+		tr:     tr,
+		codec:  s.opts.codec,
+		framed: s.opts.framing,
+		msgs:   make(chan *Message, 64),
+	}
+	go c.acceptLoop()
+	return c, nil
+}
+
+func (c *TestClient) acceptLoop() {
+	for {
+		str, err := c.Conn.AcceptStream(context.Background())
+		if err != nil {
+			return
+		}
+		go c.readStream(str)
+	}
+}
+
+func (c *TestClient) readStream(str *quic.Stream) {
+	for {
+		var data []byte
+		var err error
+		if c.framed {
+			data, err = readFrame(str, 0)
+		} else {
+			data, err = io.ReadAll(str)
+		}
+		if err != nil || len(data) == 0 {
+			return
+		}
+		var msg Message
+		if err := c.codec.Unmarshal(data, &msg); err == nil {
+			msg.codec = c.codec
+			c.msgs <- &msg
+		}
+		if !c.framed {
+			return
+		}
+	}
+}
+
+// Send envia uma mensagem ao servidor
+func (c *TestClient) Send(msg *Message) error {
+	data, err := marshalMessage(c.codec, msg)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.framed {
+		str, err := c.Conn.OpenStream()
+		if err != nil {
+			return err
+		}
+		if _, err := str.Write(data); err != nil {
+			return err
+		}
+		return str.Close()
+	}
+	if c.out == nil {
+		str, err := c.Conn.OpenStream()
+		if err != nil {
+			return err
+		}
+		c.out = str
+	}
+	return writeFrame(c.out, data)
+}
+
+// SendDatagram envia um datagrama ao servidor
+func (c *TestClient) SendDatagram(data []byte) error {
+	return c.Conn.SendDatagram(data)
+}
+
+// SendFile envia um arquivo ao servidor no formato do SendFile, entregue ao OnFile
+func (c *TestClient) SendFile(ctx context.Context, name string, r io.Reader) error {
+	hdr, err := c.codec.Marshal(FileHeader{Name: name, Size: readerSize(r)})
+	if err != nil {
+		return err
+	}
+	str, err := c.Conn.OpenUniStreamSync(ctx)
+	if err != nil {
+		return err
+	}
+	if err := writeFrame(str, hdr); err != nil {
+		return err
+	}
+	if _, err := io.Copy(str, r); err != nil {
+		str.CancelWrite(StreamCodeFileCanceled)
+		return err
+	}
+	return str.Close()
+}
+
+// ReceiveFile aguarda o próximo arquivo enviado pelo servidor com SendFile
+func (c *TestClient) ReceiveFile(ctx context.Context) (FileHeader, io.Reader, error) {
+	var hdr FileHeader
+	str, err := c.Conn.AcceptUniStream(ctx)
+	if err != nil {
+		return hdr, nil, err
+	}
+	data, err := readFrame(str, 0)
+	if err != nil {
+		return hdr, nil, err
+	}
+	if err := c.codec.Unmarshal(data, &hdr); err != nil {
+		return hdr, nil, err
+	}
+	return hdr, str, nil
+}
+
+// Receive aguarda a próxima mensagem enviada pelo servidor via stream
+func (c *TestClient) Receive(ctx context.Context) (*Message, error) {
+	select {
+	case msg := <-c.msgs:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close encerra a conexão do client
+func (c *TestClient) Close() error {
+	err := c.Conn.CloseWithError(0, "")
+	c.tr.Close()
+	return err
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// connectTestClients conecta n clients ao servidor de teste e espera que todos
+// tenham passado pelo OnConn
+func connectTestClients[T, M any](tb testing.TB, ctx context.Context, s *Server[T, M], n int) []*TestClient {
+	tb.Helper()
+	clients := make([]*TestClient, 0, n)
+	for i := 0; i < n; i++ {
+		tc, err := NewTestClient(ctx, s)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		tb.Cleanup(func() { tc.Close() })
+		clients = append(clients, tc)
+	}
+	waitFor(tb, ctx, func() bool { return len(s.GetClients()) >= n })
+	return clients
+}
+
+// waitFor espera cond ser verdadeira ou ctx expirar
+func waitFor(tb testing.TB, ctx context.Context, cond func() bool) {
+	tb.Helper()
+	for !cond() {
+		select {
+		case <-ctx.Done():
+			tb.Fatal("condition not met:", ctx.Err())
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestMemTransportLifecycle(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := make(chan *Client, 1)
+	msgs := make(chan *Message, 1)
+	discs := make(chan DisconnectInfo, 1)
+	s.OnConn = func(c *Client) { conns <- c }
+	s.OnMsg = func(_ context.Context, _ *Client, msg *Message) { msgs <- msg }
+	s.OnDisc = func(_ *Client, info DisconnectInfo) { discs <- info }
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc, err := NewTestClient(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-conns:
+	case <-ctx.Done():
+		t.Fatal("OnConn was not called")
+	}
+
+	if err := tc.Send(&Message{Type: "move", Data: []byte(`{"x":1}`)}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-msgs:
+		if msg.Type != "move" || string(msg.Data) != `{"x":1}` {
+			t.Fatalf("OnMsg got %s %s", msg.Type, msg.Data)
+		}
+	case <-ctx.Done():
+		t.Fatal("OnMsg was not called")
+	}
+
+	if err := s.BroadcastReliable(&Message{Type: "state"}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := tc.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != "state" {
+		t.Fatalf("broadcast type = %q, want state", msg.Type)
+	}
+
+	tc.Close()
+	select {
+	case info := <-discs:
+		if info.Reason != ReasonClientClosed {
+			t.Fatalf("disconnect reason = %v, want %v", info.Reason, ReasonClientClosed)
+		}
+	case <-ctx.Done():
+		t.Fatal("OnDisc was not called")
+	}
+	if n := len(s.GetClients()); n != 0 {
+		t.Fatalf("%d clients left after disconnect", n)
+	}
+}
+
+func TestMemTransportBroadcastReachesAllClients(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clients := connectTestClients(t, ctx, s, 3)
+
+	if err := s.BroadcastReliable(&Message{Type: "tick"}); err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range clients {
+		msg, err := tc.Receive(ctx)
+		if err != nil {
+			t.Fatalf("client %d: %v", i, err)
+		}
+		if msg.Type != "tick" {
+			t.Fatalf("client %d got %q, want tick", i, msg.Type)
+		}
+	}
+}
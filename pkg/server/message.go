@@ -2,6 +2,14 @@ package server
 
 import "encoding/json"
 
+// Tipos de conteúdo do payload de Message
+const (
+	// ContentTypeJSON é o padrão: o payload está em Data, embutido no envelope
+	ContentTypeJSON = "application/json"
+	// ContentTypeBinary marca bytes opacos em Binary, sem interpretação pelo servidor
+	ContentTypeBinary = "application/octet-stream"
+)
+
 type MessageInterface interface {
 	GetType() string
 	GetData() json.RawMessage
@@ -10,12 +18,64 @@ type MessageInterface interface {
 type Message struct {
 	Type string          `json:"type"`
 	Data json.RawMessage `json:"data"`
+	// Seq é o número de sequência opcional do client, validado por WithSequenceValidation
+	Seq uint64 `json:"seq,omitempty"`
+	// TraceParent é o cabeçalho W3C traceparent opcional do client, continuado por WithTracer
+	TraceParent string `json:"traceparent,omitempty"`
+	// ContentType identifica o payload em Binary (vazio = JSON em Data)
+	ContentType string `json:"content_type,omitempty"`
+	// Binary é o payload opaco, usado no lugar de Data. Codecs binários o levam
+	// como bytes; no JSON ele vai em base64.
+	Binary []byte `json:"binary,omitempty"`
+
+	// codec é o codec do servidor que recebeu a mensagem, usado pelo Decode
+	codec Codec
+	// payload é o valor original do NewTypedMessage, serializado de novo no
+	// envio quando o codec do servidor não é JSON
+	payload any
 }
 
 func (m *Message) GetType() string {
 	return m.Type
 }
 
+// GetData retorna o payload JSON. Em mensagens binárias com ContentType que
+// não é JSON retorna nil: os bytes opacos ficam em Bytes.
 func (m *Message) GetData() json.RawMessage {
+	if !m.IsBinary() {
+		return m.Data
+	}
+	if !m.IsJSON() {
+		return nil
+	}
+	return m.Binary
+}
+
+// Bytes retorna o payload como está: Binary, se a mensagem for binária, ou Data
+func (m *Message) Bytes() []byte {
+	if m.IsBinary() {
+		return m.Binary
+	}
 	return m.Data
 }
+
+// IsJSON indica que o payload é JSON (ContentType vazio ou ContentTypeJSON)
+func (m *Message) IsJSON() bool {
+	return m.ContentType == "" || m.ContentType == ContentTypeJSON
+}
+
+// IsBinary indica que o payload está em Binary
+func (m *Message) IsBinary() bool {
+	return m.Binary != nil || (m.ContentType != "" && m.ContentType != ContentTypeJSON)
+}
+
+// NewBinaryMessage cria uma mensagem com payload opaco; contentType vazio vira ContentTypeBinary
+func NewBinaryMessage(msgType, contentType string, data []byte) *Message {
+	if contentType == "" {
+		contentType = ContentTypeBinary
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return &Message{Type: msgType, ContentType: contentType, Binary: data}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBinaryMessagePayload(t *testing.T) {
+	frame := []byte{0xff, 0x00, '{'}
+	msg := NewBinaryMessage("voice", "audio/opus", frame)
+
+	if got := msg.Bytes(); !bytes.Equal(got, frame) {
+		t.Fatalf("Bytes() = %x, want %x", got, frame)
+	}
+	if got := msg.GetData(); got != nil {
+		t.Fatalf("GetData() = %x, want nil for non-JSON payload", got)
+	}
+	raw, err := Decode[[]byte](msg)
+	if err != nil || !bytes.Equal(raw, frame) {
+		t.Fatalf("Decode[[]byte] = %x, %v", raw, err)
+	}
+	if _, err := Decode[map[string]any](msg); !errors.Is(err, errNotJSON) {
+		t.Fatalf("Decode[map] err = %v, want errNotJSON", err)
+	}
+
+	// Binary com ContentType JSON continua sendo lido como JSON
+	msg = &Message{Type: "state", ContentType: ContentTypeJSON, Binary: []byte(`{"x":1}`)}
+	v, err := Decode[struct{ X int }](msg)
+	if err != nil || v.X != 1 {
+		t.Fatalf("Decode JSON binary = %+v, %v", v, err)
+	}
+}
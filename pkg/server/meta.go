@@ -0,0 +1,14 @@
+package server
+
+// GetMetaAs retorna o valor de meta em key já com o tipo V. Retorna false se a
+// chave não existir ou guardar um valor de outro tipo, sem panic.
+func GetMetaAs[V any](c ClientInterface, key string) (V, bool) {
+	v, ok := c.GetMeta()[key].(V)
+	return v, ok
+}
+
+// SetMetaAs guarda value em key; junto com GetMetaAs garante que leitura e
+// escrita da chave usem o mesmo tipo
+func SetMetaAs[V any](c ClientInterface, key string, value V) {
+	c.SetMeta(key, value)
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/logging"
+)
+
+// connMetricsKey é a chave do contexto da conexão onde as métricas ficam guardadas
+type connMetricsKey struct{}
+
+// connMetrics guarda as métricas de transporte reportadas pelo quic-go
+type connMetrics struct {
+	rtt           atomic.Int64
+	minRTT        atomic.Int64
+	cwnd          atomic.Int64
+	bytesInFlight atomic.Int64
+	packetsSent   atomic.Uint64
+	packetsLost   atomic.Uint64
+}
+
+// ConnStats é um retrato da saúde de transporte de uma conexão
+type ConnStats struct {
+	// RTT é o RTT suavizado e MinRTT o menor RTT observado
+	RTT    time.Duration
+	MinRTT time.Duration
+	// CongestionWindow e BytesInFlight são medidos em bytes
+	CongestionWindow int
+	BytesInFlight    int
+	PacketsSent      uint64
+	PacketsLost      uint64
+	// LossRate é a fração de pacotes enviados declarados perdidos (0 a 1)
+	LossRate float64
+}
+
+// withMetricsTracer retorna uma cópia da configuração QUIC cujo tracer alimenta o connMetrics
+func withMetricsTracer(conf *quic.Config) *quic.Config {
+	if conf == nil {
+		conf = &quic.Config{}
+	}
+	conf = conf.Clone()
+	prev := conf.Tracer
+	conf.Tracer = func(ctx context.Context, p logging.Perspective, id quic.ConnectionID) *logging.ConnectionTracer {
+		var user *logging.ConnectionTracer
+		if prev != nil {
+			user = prev(ctx, p, id)
+		}
+		m, ok := ctx.Value(connMetricsKey{}).(*connMetrics)
+		if !ok {
+			return user
+		}
+		if user == nil {
+			return m.tracer()
+		}
+		return logging.NewMultiplexedConnectionTracer(user, m.tracer())
+	}
+	return conf
+}
+
+func (m *connMetrics) tracer() *logging.ConnectionTracer {
+	return &logging.ConnectionTracer{
+		UpdatedMetrics: func(rttStats *logging.RTTStats, cwnd, bytesInFlight logging.ByteCount, packetsInFlight int) {
+			m.rtt.Store(int64(rttStats.SmoothedRTT()))
+			m.minRTT.Store(int64(rttStats.MinRTT()))
+			m.cwnd.Store(int64(cwnd))
+			m.bytesInFlight.Store(int64(bytesInFlight))
+		},
+		SentLongHeaderPacket: func(*logging.ExtendedHeader, logging.ByteCount, logging.ECN, *logging.AckFrame, []logging.Frame) {
+			m.packetsSent.Add(1)
+		},
+		SentShortHeaderPacket: func(*logging.ShortHeader, logging.ByteCount, logging.ECN, *logging.AckFrame, []logging.Frame) {
+			m.packetsSent.Add(1)
+		},
+		LostPacket: func(logging.EncryptionLevel, logging.PacketNumber, logging.PacketLossReason) {
+			m.packetsLost.Add(1)
+		},
+	}
+}
+
+func (m *connMetrics) stats() ConnStats {
+	st := ConnStats{
+		RTT:              time.Duration(m.rtt.Load()),
+		MinRTT:           time.Duration(m.minRTT.Load()),
+		CongestionWindow: int(m.cwnd.Load()),
+		BytesInFlight:    int(m.bytesInFlight.Load()),
+		PacketsSent:      m.packetsSent.Load(),
+		PacketsLost:      m.packetsLost.Load(),
+	}
+	if st.PacketsSent > 0 {
+		st.LossRate = float64(st.PacketsLost) / float64(st.PacketsSent)
+	}
+	return st
+}
+
+// metricsFromContext retorna as métricas anexadas ao contexto da conexão (ou nil)
+func metricsFromContext(ctx context.Context) *connMetrics {
+	m, _ := ctx.Value(connMetricsKey{}).(*connMetrics)
+	return m
+}
+
+// RTT retorna o RTT suavizado medido pelo QUIC (0 se ainda não houver amostra)
+func (c *Conn) RTT() time.Duration {
+	if c.metrics == nil {
+		return 0
+	}
+	return time.Duration(c.metrics.rtt.Load())
+}
+
+// QUICStats retorna as estatísticas de transporte da conexão (zeradas se indisponíveis)
+func (c *Conn) QUICStats() ConnStats {
+	if c.metrics == nil {
+		return ConnStats{}
+	}
+	return c.metrics.stats()
+}
+
+// aggregateConnStats preenche em st as médias das estatísticas das conexões com amostra
+func (s *Server[T, M]) aggregateConnStats(st *Stats) {
+	var rtt time.Duration
+	var cwnd, inFlight, n int
+	var loss float64
+	s.conns.Range(func(conn *Conn, _ T) bool {
+		cs := conn.QUICStats()
+		if cs.RTT > 0 {
+			rtt += cs.RTT
+			cwnd += cs.CongestionWindow
+			inFlight += cs.BytesInFlight
+			loss += cs.LossRate
+			n++
+		}
+		return true
+	})
+	if n == 0 {
+		return
+	}
+	st.AvgRTT = rtt / time.Duration(n)
+	st.AvgCongestionWindow = cwnd / n
+	st.AvgBytesInFlight = inFlight / n
+	st.AvgLossRate = loss / float64(n)
+}
+
+// GetClientsByRTT retorna os clients ordenados do menor para o maior RTT
+func (s *Server[T, M]) GetClientsByRTT() []T {
+	type entry struct {
+		client T
+		rtt    time.Duration
+	}
+	var entries []entry
+	s.conns.Range(func(conn *Conn, client T) bool {
+		entries = append(entries, entry{client: client, rtt: conn.RTT()})
+		return true
+	})
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].rtt < entries[j].rtt })
+	clients := make([]T, len(entries))
+	for i, e := range entries {
+		clients[i] = e.client
+	}
+	return clients
+}
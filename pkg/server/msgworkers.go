@@ -0,0 +1,78 @@
+package server
+
+import "sync"
+
+// WithMessageWorkers processa os handlers de mensagem em n workers fixos em vez
+// de na goroutine de cada stream, limitando a concorrência (0 = desabilitado)
+func WithMessageWorkers(n int) Option {
+	return func(o *options) {
+		o.messageWorkers = n
+	}
+}
+
+// WithOrderedMessages faz as mensagens de um mesmo client serem sempre
+// processadas pelo mesmo worker, na ordem de chegada. Requer WithMessageWorkers.
+func WithOrderedMessages(enabled bool) Option {
+	return func(o *options) {
+		o.orderedMessages = enabled
+	}
+}
+
+// messagePool executa os handlers de mensagem em um número fixo de workers.
+// No modo ordenado cada worker tem sua fila e o client é roteado por conexão.
+type messagePool struct {
+	queues []chan func()
+	mu     sync.RWMutex
+	closed bool
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+func newMessagePool(workers int, ordered bool) *messagePool {
+	p := &messagePool{}
+	queues := 1
+	if ordered {
+		queues = workers
+	}
+	for i := 0; i < queues; i++ {
+		p.queues = append(p.queues, make(chan func(), 256))
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.worker(p.queues[i%queues])
+	}
+	return p
+}
+
+func (p *messagePool) worker(jobs chan func()) {
+	defer p.wg.Done()
+	for job := range jobs {
+		job()
+	}
+}
+
+// submit enfileira o job na fila da conexão, bloqueando se a fila estiver cheia.
+// Retorna false sem executar o job se o pool já foi fechado.
+func (p *messagePool) submit(conn *Conn, job func()) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return false
+	}
+	p.queues[conn.seq%uint64(len(p.queues))] <- job
+	return true
+}
+
+// close recusa novos jobs e espera os pendentes terminarem. Pode ser chamado
+// mais de uma vez.
+func (p *messagePool) close() {
+	p.once.Do(func() {
+		p.mu.Lock()
+		p.closed = true
+		for _, q := range p.queues {
+			close(q)
+		}
+		p.mu.Unlock()
+	})
+	p.wg.Wait()
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMessagePoolSubmitRacingClose(t *testing.T) {
+	p := newMessagePool(4, true)
+	var ran, accepted atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn := &Conn{seq: uint64(i)}
+			for j := 0; j < 200; j++ {
+				if p.submit(conn, func() { ran.Add(1) }) {
+					accepted.Add(1)
+				}
+			}
+		}(i)
+	}
+	p.close()
+	p.close()
+	wg.Wait()
+	if ran.Load() != accepted.Load() {
+		t.Fatalf("ran %d jobs, accepted %d", ran.Load(), accepted.Load())
+	}
+	if p.submit(&Conn{}, func() { t.Error("job ran after close") }) {
+		t.Fatal("submit accepted a job after close")
+	}
+}
+
+func TestStopTwiceWithMessageWorkers(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithMessageWorkers(4), WithOrderedMessages(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	s.Stop()
+	s.Stop()
+}
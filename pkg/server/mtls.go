@@ -0,0 +1,52 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+)
+
+// MetaKeyCertSubject é a chave de meta onde o subject do certificado do client é salvo
+const MetaKeyCertSubject = "tls.subject"
+
+// WithClientAuth exige (required) ou aceita certificados de client assinados por caPool
+func WithClientAuth(caPool *x509.CertPool, required bool) Option {
+	return func(o *options) {
+		o.clientCAs = caPool
+		o.clientCertRequired = required
+	}
+}
+
+// applyClientAuth configura a verificação de certificados de client no tls.Config
+func applyClientAuth(conf *tls.Config, o options) *tls.Config {
+	if o.clientCAs == nil {
+		return conf
+	}
+	conf = conf.Clone()
+	conf.ClientCAs = o.clientCAs
+	if o.clientCertRequired {
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		conf.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return conf
+}
+
+// PeerCertificate retorna o certificado verificado do client, se houver
+func (c *Conn) PeerCertificate() *x509.Certificate {
+	certs := c.ConnectionState().TLS.PeerCertificates
+	if len(certs) == 0 {
+		return nil
+	}
+	return certs[0]
+}
+
+// applyCertMeta salva o subject do certificado do client na meta antes do OnConn
+func applyCertMeta(conn *Conn, client any) {
+	cert := conn.PeerCertificate()
+	if cert == nil {
+		return
+	}
+	if c, ok := client.(ClientInterface); ok {
+		c.SetMeta(MetaKeyCertSubject, cert.Subject.String())
+	}
+}
@@ -0,0 +1,27 @@
+package server
+
+import "context"
+
+// WithAsyncOnConn faz o OnConn rodar em paralelo com a leitura das streams do
+// client. As mensagens e datagramas que chegarem antes dele retornar ficam
+// aguardando e são entregues em seguida, então o OnConn sempre termina antes do
+// primeiro OnMsg do client, nos dois modos. No modo padrão (síncrono) as streams
+// só começam a ser aceitas depois do OnConn.
+func WithAsyncOnConn(enabled bool) Option {
+	return func(o *options) {
+		o.asyncOnConn = enabled
+	}
+}
+
+// awaitReady espera o OnConn da conexão terminar. Retorna false se ctx terminar antes.
+func awaitReady(ctx context.Context, conn *Conn) bool {
+	if conn.ready == nil {
+		return true
+	}
+	select {
+	case <-conn.ready:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAsyncOnConnQueuesEarlyMessages(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithAsyncOnConn(true), WithFraming(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	events := make(chan string, 8)
+	s.OnConn = func(*Client) {
+		close(started)
+		<-release
+		events <- "conn"
+	}
+	s.OnMsg = func(_ context.Context, _ *Client, msg *Message) { events <- msg.Type }
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc, err := NewTestClient(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tc.Close()
+	select {
+	case <-started:
+	case <-ctx.Done():
+		t.Fatal("OnConn was not called")
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := tc.Send(&Message{Type: fmt.Sprint("m", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// As mensagens chegam enquanto o OnConn ainda roda e precisam esperar
+	select {
+	case ev := <-events:
+		t.Fatalf("%q delivered before OnConn returned", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	for _, want := range []string{"conn", "m1", "m2", "m3"} {
+		select {
+		case got := <-events:
+			if got != want {
+				t.Fatalf("got %q, want %q", got, want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("%s was not delivered", want)
+		}
+	}
+}
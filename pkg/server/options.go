@@ -0,0 +1,319 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configura parâmetros opcionais do servidor
+type Option func(*options)
+
+type options struct {
+	tickRate           int
+	tlsConfig          *tls.Config
+	quicConfig         *quic.Config
+	maxConns           int
+	msgRate            int
+	msgBurst           int
+	rateLimitPolicy    RateLimitPolicy
+	maxMessageSize     int
+	broadcastWorkers   int
+	codec              Codec
+	spatialCellSize    float64
+	maxCatchUpTicks    int
+	framing            bool
+	clientCAs          *x509.CertPool
+	clientCertRequired bool
+	authenticator      Authenticator
+	authTimeout        time.Duration
+	addressValidation  bool
+	verifySourceAddr   func(net.Addr) bool
+	reusePort          int
+	udpBufferSize      int
+	webTransportPath   string
+	maxMalformed       int
+	reliableWindow     int
+	certs              *certStore
+	minTLSVersion      uint16
+	cipherSuites       []uint16
+	alpn               []string
+	idGenerator        func() string
+	bans               *banList
+	allowedCIDRs       []*net.IPNet
+	deniedCIDRs        []*net.IPNet
+	reconnectWindow    time.Duration
+	reconnectSecret    []byte
+	sessionTokenTTL    time.Duration
+	handlerTimeout     time.Duration
+	breakerThreshold   int
+	breakerCooldown    time.Duration
+	messageWorkers     int
+	orderedMessages    bool
+	sendQueueSize      int
+	slowClientPolicy   SlowClientPolicy
+	compression        Compression
+	compressThreshold  int
+	seqWindow          int
+	reorderSize        int
+	reorderTimeout     time.Duration
+	asyncOnConn        bool
+	adaptiveMinRate    int
+	adaptiveMaxRate    int
+	drainTimeout       time.Duration
+	tickCoalescing     bool
+	tracer             trace.Tracer
+	bandwidthQuota     int64
+	orderedBroadcasts  bool
+	writeTimeout       time.Duration
+	readTimeout        time.Duration
+	maxStreamsPerConn  int
+	streamLimitPolicy  StreamLimitPolicy
+	eventBuffer        int
+	eventPolicy        EventDropPolicy
+	handshakeTimeout   time.Duration
+	shutdownTimeout    time.Duration
+	randSeed           int64
+	randSeeded         bool
+	maxRPCCalls        int
+}
+
+const (
+	// DefaultTickRate é a taxa padrão de ticks por segundo
+	DefaultTickRate = 60
+	// DefaultMaxMessageSize é o tamanho máximo padrão de uma mensagem (4MB)
+	DefaultMaxMessageSize = 4 * 1024 * 1024
+	// DefaultALPN é o protocolo ALPN anunciado quando nenhum outro é configurado
+	DefaultALPN = "go-mp/1"
+)
+
+func defaultOptions() options {
+	return options{
+		tickRate:          DefaultTickRate,
+		quicConfig:        defaultQUICConfig(),
+		maxMessageSize:    DefaultMaxMessageSize,
+		codec:             JSONCodec{},
+		authTimeout:       DefaultAuthTimeout,
+		idGenerator:       RandomID,
+		compressThreshold: DefaultCompressionThreshold,
+		drainTimeout:      DefaultDrainTimeout,
+		shutdownTimeout:   DefaultShutdownTimeout,
+		sessionTokenTTL:   DefaultSessionTokenTTL,
+		maxRPCCalls:       DefaultMaxRPCCalls,
+	}
+}
+
+func buildOptions(opts []Option) (options, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.tickRate <= 0 {
+		return o, fmt.Errorf("%w: tick rate %d", ErrInvalidOption, o.tickRate)
+	}
+	if o.adaptiveMaxRate > 0 {
+		if o.adaptiveMinRate <= 0 || o.adaptiveMinRate > o.adaptiveMaxRate {
+			return o, fmt.Errorf("%w: adaptive tick range %d-%d", ErrInvalidOption, o.adaptiveMinRate, o.adaptiveMaxRate)
+		}
+		o.tickRate = min(max(o.tickRate, o.adaptiveMinRate), o.adaptiveMaxRate)
+	}
+	if o.orderedBroadcasts && o.sendQueueSize <= 0 {
+		o.sendQueueSize = DefaultBroadcastQueueSize
+	}
+	if err := o.validateTLSPolicy(); err != nil {
+		return o, err
+	}
+	o.quicConfig = withMetricsTracer(o.quicConfig)
+	o.applyStreamLimit()
+	o.applyHandshakeTimeout()
+	o.certs = &certStore{}
+	o.bans = newBanList()
+	if o.reconnectSecret == nil {
+		o.reconnectSecret = randomSecret()
+	}
+	return o, nil
+}
+
+// serverTLSConfig retorna a configuração TLS do listener
+func (o options) serverTLSConfig() *tls.Config {
+	conf := o.tlsConfig
+	if conf == nil {
+		conf = GenerateTLSConfig()
+	}
+	conf = applyClientAuth(conf, o)
+	conf = applyTLSPolicy(conf, o)
+	conf = applyCertReload(conf, o.certs)
+	conf.NextProtos = o.alpnProtocols()
+	if o.compression != CompressionNone {
+		conf.NextProtos = compressionALPN(conf.NextProtos)
+	}
+	if o.webTransportPath != "" {
+		// Anuncia o ALPN do HTTP/3 para que navegadores possam negociar WebTransport
+		conf.NextProtos = append(conf.NextProtos, http3.NextProtoH3)
+	}
+	return conf
+}
+
+// alpnProtocols retorna os protocolos ALPN do jogo que os clients devem negociar
+func (o options) alpnProtocols() []string {
+	if len(o.alpn) > 0 {
+		return slices.Clone(o.alpn)
+	}
+	if o.tlsConfig != nil && len(o.tlsConfig.NextProtos) > 0 {
+		return slices.Clone(o.tlsConfig.NextProtos)
+	}
+	return []string{DefaultALPN}
+}
+
+// WithALPN define os protocolos ALPN aceitos (padrão: DefaultALPN). Clients que
+// não negociarem um deles têm o handshake recusado.
+func WithALPN(protocols ...string) Option {
+	return func(o *options) {
+		o.alpn = protocols
+	}
+}
+
+func defaultQUICConfig() *quic.Config {
+	return &quic.Config{
+		EnableDatagrams:                true,
+		MaxIdleTimeout:                 5 * time.Minute,
+		MaxIncomingStreams:             1000, // Aumentar limite de streams
+		MaxIncomingUniStreams:          1000,
+		InitialStreamReceiveWindow:     512 * 1024,  // 512KB
+		MaxStreamReceiveWindow:         1024 * 1024, // 1MB
+		InitialConnectionReceiveWindow: 1024 * 1024, // 1MB
+		MaxConnectionReceiveWindow:     1024 * 1024, // 1MB
+	}
+}
+
+// WithTickRate define quantos ticks por segundo o TickFn é chamado (padrão: 60)
+func WithTickRate(n int) Option {
+	return func(o *options) {
+		o.tickRate = n
+	}
+}
+
+// WithFixedTimestep faz o TickFn rodar com passo fixo, executando até maxCatchUp
+// ticks por iteração para recuperar atrasos. Nesse modo dt é sempre o intervalo do tick.
+func WithFixedTimestep(maxCatchUp int) Option {
+	return func(o *options) {
+		o.maxCatchUpTicks = maxCatchUp
+	}
+}
+
+// WithTLSConfig usa a configuração TLS fornecida em vez do certificado autoassinado
+func WithTLSConfig(c *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = c
+	}
+}
+
+// WithQUICConfig substitui a configuração QUIC padrão
+func WithQUICConfig(c *quic.Config) Option {
+	return func(o *options) {
+		o.quicConfig = c
+	}
+}
+
+// WithMaxConnections limita o número de conexões simultâneas (0 = sem limite)
+func WithMaxConnections(n int) Option {
+	return func(o *options) {
+		o.maxConns = n
+	}
+}
+
+// WithMessageRateLimit limita as mensagens recebidas por client (token bucket).
+// Cada frame, stream ou datagrama conta antes da decodificação, inclusive os
+// malformados ou maiores que WithMaxMessageSize.
+func WithMessageRateLimit(perSecond, burst int) Option {
+	return func(o *options) {
+		o.msgRate = perSecond
+		o.msgBurst = burst
+	}
+}
+
+// WithRateLimitPolicy define se mensagens excedentes são descartadas ou se o client é desconectado
+func WithRateLimitPolicy(p RateLimitPolicy) Option {
+	return func(o *options) {
+		o.rateLimitPolicy = p
+	}
+}
+
+// WithMaxMessageSize define o tamanho máximo em bytes de uma mensagem recebida (0 = sem limite).
+// Com framing o prefixo de comprimento é checado antes de qualquer alocação.
+func WithMaxMessageSize(bytes int) Option {
+	return func(o *options) {
+		o.maxMessageSize = bytes
+	}
+}
+
+// WithBroadcastWorkers distribui os envios de broadcast entre n workers (0 = envio em série)
+func WithBroadcastWorkers(n int) Option {
+	return func(o *options) {
+		o.broadcastWorkers = n
+	}
+}
+
+// WithCodec define o Codec usado para serializar mensagens (padrão: JSONCodec)
+func WithCodec(c Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// WithSpatialCellSize habilita o índice espacial usado por BroadcastNearby com o tamanho de célula dado
+func WithSpatialCellSize(size float64) Option {
+	return func(o *options) {
+		o.spatialCellSize = size
+	}
+}
+
+// WithFraming habilita mensagens com prefixo de comprimento, permitindo várias
+// mensagens por stream e streams de saída persistentes
+func WithFraming(enabled bool) Option {
+	return func(o *options) {
+		o.framing = enabled
+	}
+}
+
+// WithAddressValidation exige que todo client valide seu endereço com um Retry
+// do QUIC antes do handshake. Protege contra amplificação e endereços forjados
+// ao custo de um round trip extra na conexão.
+func WithAddressValidation(enabled bool) Option {
+	return func(o *options) {
+		o.addressValidation = enabled
+	}
+}
+
+// WithVerifySourceAddr decide por conexão se o endereço deve ser validado com Retry.
+// Tem precedência sobre WithAddressValidation.
+func WithVerifySourceAddr(fn func(addr net.Addr) bool) Option {
+	return func(o *options) {
+		o.verifySourceAddr = fn
+	}
+}
+
+// sourceAddrVerifier retorna a função usada em quic.Transport.VerifySourceAddress
+func (o options) sourceAddrVerifier() func(net.Addr) bool {
+	if o.verifySourceAddr != nil {
+		return o.verifySourceAddr
+	}
+	if o.addressValidation {
+		return func(net.Addr) bool { return true }
+	}
+	return nil
+}
+
+// WithMaxMalformedMessages desconecta o client após n mensagens malformadas (0 = nunca)
+func WithMaxMalformedMessages(n int) Option {
+	return func(o *options) {
+		o.maxMalformed = n
+	}
+}
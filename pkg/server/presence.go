@@ -0,0 +1,118 @@
+package server
+
+import (
+	"slices"
+	"time"
+)
+
+// RoomMember é implementado por clients que pertencem a uma sala
+type RoomMember interface {
+	GetRoom() string
+}
+
+// Tagged é implementado por clients que expõem tags
+type Tagged interface {
+	GetTags() []string
+}
+
+// PresenceEntry descreve um client online
+type PresenceEntry struct {
+	ID          string
+	Room        string
+	Tags        []string
+	ConnectedAt time.Time
+}
+
+// PresenceEventKind indica se o client entrou ou saiu
+type PresenceEventKind int
+
+const (
+	PresenceJoined PresenceEventKind = iota
+	PresenceLeft
+)
+
+// PresenceEvent é entregue ao OnPresenceChange quando um client conecta ou desconecta
+type PresenceEvent struct {
+	Kind  PresenceEventKind
+	Entry PresenceEntry
+}
+
+// Presence retorna os clients online no momento
+func (s *Server[T, M]) Presence() []PresenceEntry {
+	return s.presence("", false)
+}
+
+// PresenceIn retorna os clients online em uma sala (clients que implementam RoomMember)
+func (s *Server[T, M]) PresenceIn(room string) []PresenceEntry {
+	return s.presence(room, true)
+}
+
+func (s *Server[T, M]) presence(room string, scoped bool) []PresenceEntry {
+	entries := make([]PresenceEntry, 0, s.connCount.Load())
+	s.conns.Range(func(conn *Conn, c T) bool {
+		e := presenceEntry(conn, c)
+		if !scoped || e.Room == room {
+			entries = append(entries, e)
+		}
+		return true
+	})
+	return entries
+}
+
+func presenceEntry(conn *Conn, client any) PresenceEntry {
+	e := PresenceEntry{ID: clientID(conn, client), ConnectedAt: conn.connectedAt}
+	if r, ok := client.(RoomMember); ok {
+		e.Room = r.GetRoom()
+	}
+	if t, ok := client.(Tagged); ok {
+		e.Tags = t.GetTags()
+	}
+	return e
+}
+
+func (s *Server[T, M]) notifyPresence(kind PresenceEventKind, conn *Conn, c T) {
+	if s.OnPresenceChange == nil {
+		return
+	}
+	s.OnPresenceChange(PresenceEvent{Kind: kind, Entry: presenceEntry(conn, c)})
+}
+
+// ClientInfo é o retrato de um client para inspeção (ListClients e /clients do StartAdmin)
+type ClientInfo struct {
+	ID               string        `json:"id"`
+	RemoteAddr       string        `json:"remote_addr"`
+	ConnectedAt      time.Time     `json:"connected_at"`
+	Room             string        `json:"room,omitempty"`
+	Tags             []string      `json:"tags,omitempty"`
+	MessagesSent     int64         `json:"messages_sent"`
+	MessagesReceived int64         `json:"messages_received"`
+	BytesSent        int64         `json:"bytes_sent"`
+	BytesReceived    int64         `json:"bytes_received"`
+	RTT              time.Duration `json:"rtt"`
+}
+
+// ListClients retorna um retrato de cada client conectado. Pode ser chamado a
+// qualquer momento; cada entrada é lida de uma vez e não muda depois de retornada.
+func (s *Server[T, M]) ListClients() []ClientInfo {
+	clients := make([]ClientInfo, 0, s.connCount.Load())
+	s.conns.Range(func(conn *Conn, c T) bool {
+		e := presenceEntry(conn, c)
+		if len(e.Tags) == 0 {
+			e.Tags = conn.tagIndex().tagsOf(conn)
+		}
+		clients = append(clients, ClientInfo{
+			ID:               e.ID,
+			RemoteAddr:       conn.RemoteAddr().String(),
+			ConnectedAt:      e.ConnectedAt,
+			Room:             e.Room,
+			Tags:             slices.Clone(e.Tags),
+			MessagesSent:     conn.msgsOut.Load(),
+			MessagesReceived: conn.msgsIn.Load(),
+			BytesSent:        conn.bytesOut.Load(),
+			BytesReceived:    conn.bytesIn.Load(),
+			RTT:              conn.RTT(),
+		})
+		return true
+	})
+	return clients
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidTopic indica um tópico ou padrão de inscrição mal formado
+var ErrInvalidTopic = errors.New("invalid topic")
+
+// topicIndex guarda as inscrições numa trie por segmento do tópico ("game.42.events"),
+// então o Publish visita só os ramos que podem casar em vez de testar cada padrão
+type topicIndex struct {
+	mu     sync.RWMutex
+	root   topicNode
+	byConn map[*Conn]map[string]struct{}
+}
+
+type topicNode struct {
+	children map[string]*topicNode
+	subs     map[*Conn]struct{}
+}
+
+func newTopicIndex() *topicIndex {
+	return &topicIndex{byConn: make(map[*Conn]map[string]struct{})}
+}
+
+// validTopic verifica os segmentos; "*" e "#" só valem em padrões, e "#" só no fim
+func validTopic(topic string, pattern bool) error {
+	segs := strings.Split(topic, ".")
+	for i, seg := range segs {
+		switch {
+		case seg == "":
+			return fmt.Errorf("%w: %q has an empty segment", ErrInvalidTopic, topic)
+		case (seg == "*" || seg == "#") && !pattern:
+			return fmt.Errorf("%w: %q has a wildcard", ErrInvalidTopic, topic)
+		case seg == "#" && i != len(segs)-1:
+			return fmt.Errorf("%w: %q has # before the last segment", ErrInvalidTopic, topic)
+		}
+	}
+	return nil
+}
+
+func (ix *topicIndex) add(conn *Conn, pattern string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	node := &ix.root
+	for _, seg := range strings.Split(pattern, ".") {
+		if node.children == nil {
+			node.children = make(map[string]*topicNode)
+		}
+		child := node.children[seg]
+		if child == nil {
+			child = &topicNode{}
+			node.children[seg] = child
+		}
+		node = child
+	}
+	if node.subs == nil {
+		node.subs = make(map[*Conn]struct{})
+	}
+	node.subs[conn] = struct{}{}
+	if ix.byConn[conn] == nil {
+		ix.byConn[conn] = make(map[string]struct{})
+	}
+	ix.byConn[conn][pattern] = struct{}{}
+}
+
+func (ix *topicIndex) remove(conn *Conn, pattern string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeLocked(conn, pattern)
+}
+
+func (ix *topicIndex) removeLocked(conn *Conn, pattern string) {
+	if patterns := ix.byConn[conn]; patterns != nil {
+		delete(patterns, pattern)
+		if len(patterns) == 0 {
+			delete(ix.byConn, conn)
+		}
+	}
+	ix.root.prune(strings.Split(pattern, "."), conn)
+}
+
+// prune remove a inscrição e os nós que ficaram vazios no caminho
+func (n *topicNode) prune(segs []string, conn *Conn) {
+	if len(segs) == 0 {
+		delete(n.subs, conn)
+		return
+	}
+	child := n.children[segs[0]]
+	if child == nil {
+		return
+	}
+	child.prune(segs[1:], conn)
+	if len(child.subs) == 0 && len(child.children) == 0 {
+		delete(n.children, segs[0])
+	}
+}
+
+// removeConn remove todas as inscrições da conexão
+func (ix *topicIndex) removeConn(conn *Conn) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for pattern := range ix.byConn[conn] {
+		ix.removeLocked(conn, pattern)
+	}
+}
+
+func (ix *topicIndex) patternsOf(conn *Conn) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	patterns := make([]string, 0, len(ix.byConn[conn]))
+	for pattern := range ix.byConn[conn] {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// match retorna as conexões com algum padrão que casa com o tópico, sem repetição
+func (ix *topicIndex) match(topic string) map[*Conn]struct{} {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	conns := make(map[*Conn]struct{})
+	ix.root.match(strings.Split(topic, "."), conns)
+	return conns
+}
+
+func (n *topicNode) match(segs []string, out map[*Conn]struct{}) {
+	// "#" casa com zero ou mais segmentos restantes
+	if rest := n.children["#"]; rest != nil {
+		for conn := range rest.subs {
+			out[conn] = struct{}{}
+		}
+	}
+	if len(segs) == 0 {
+		for conn := range n.subs {
+			out[conn] = struct{}{}
+		}
+		return
+	}
+	if child := n.children[segs[0]]; child != nil {
+		child.match(segs[1:], out)
+	}
+	if child := n.children["*"]; child != nil {
+		child.match(segs[1:], out)
+	}
+}
+
+// Subscribe inscreve o client no padrão de tópicos. Os tópicos são segmentos
+// separados por ponto; no padrão, "*" casa com exatamente um segmento e "#" (só
+// no fim) com qualquer número deles: "game.42.*" recebe "game.42.events" e
+// "game.#" recebe tudo de "game". As inscrições saem junto com o client.
+func (s *Server[T, M]) Subscribe(c T, pattern string) error {
+	conn, err := s.connOf(c)
+	if err != nil {
+		return err
+	}
+	if err := validTopic(pattern, true); err != nil {
+		return err
+	}
+	s.topics.add(conn, pattern)
+	return nil
+}
+
+// Unsubscribe remove a inscrição do client no padrão
+func (s *Server[T, M]) Unsubscribe(c T, pattern string) {
+	if conn, err := s.connOf(c); err == nil {
+		s.topics.remove(conn, pattern)
+	}
+}
+
+// Subscriptions retorna os padrões em que o client está inscrito, em ordem alfabética
+func (s *Server[T, M]) Subscriptions(c T) []string {
+	conn, err := s.connOf(c)
+	if err != nil {
+		return nil
+	}
+	return s.topics.patternsOf(conn)
+}
+
+// Publish envia a mensagem por stream para os clients inscritos em algum padrão
+// que case com o tópico; cada client recebe uma cópia mesmo que vários padrões casem
+func (s *Server[T, M]) Publish(topic string, msg *Message) error {
+	if err := validTopic(topic, false); err != nil {
+		return err
+	}
+	conns := s.topics.match(topic)
+	if len(conns) == 0 {
+		return nil
+	}
+	data, buf, err := s.marshalBroadcast(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	defer releaseBuffer(buf)
+	targets := make([]broadcastTarget, 0, len(conns))
+	for conn := range conns {
+		if client, ok := s.conns.Load(conn); ok {
+			targets = append(targets, broadcastTarget{conn: conn, id: clientID(conn, client)})
+		}
+	}
+	return s.deliver(targets, data, (*Conn).sendRaw)
+}
+
+// connOf retorna a conexão ativa do client
+func (s *Server[T, M]) connOf(c T) (*Conn, error) {
+	ci, ok := any(c).(ClientInterface)
+	if !ok {
+		return nil, errors.New("client does not implement ClientInterface")
+	}
+	conn := ci.GetConn()
+	if conn == nil {
+		return nil, ErrClientNotFound
+	}
+	if _, ok := s.conns.Load(conn); !ok {
+		return nil, ErrClientNotFound
+	}
+	return conn, nil
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WithRandSeed define a semente do Rand do servidor, para sorteios
+// reproduzíveis (padrão: derivada do horário de criação)
+func WithRandSeed(seed int64) Option {
+	return func(o *options) {
+		o.randSeed, o.randSeeded = seed, true
+	}
+}
+
+// lockedSource é uma rand.Source segura para uso por várias goroutines
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func newLockedSource(seed int64) *lockedSource {
+	return &lockedSource{src: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (l *lockedSource) Int63() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.src.Int63()
+}
+
+func (l *lockedSource) Uint64() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.src.Uint64()
+}
+
+func (l *lockedSource) Seed(seed int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.src.Seed(seed)
+}
+
+// initRand cria o Rand do servidor com a semente de WithRandSeed
+func (s *Server[T, M]) initRand() {
+	seed := s.opts.randSeed
+	if !s.opts.randSeeded {
+		seed = time.Now().UnixNano()
+	}
+	s.randSrc = newLockedSource(seed)
+	s.rand = rand.New(s.randSrc)
+}
+
+// Rand retorna o gerador de números aleatórios do servidor. Use-o no TickFn e
+// nos handlers em vez das funções globais de math/rand: com WithRandSeed a
+// sequência é reproduzível, e StartRecording grava a semente para o Replay.
+// Pode ser usado por várias goroutines, exceto Read e Seed, que guardam estado
+// próprio no *rand.Rand sem lock. A sequência só se repete se as chamadas vierem
+// na mesma ordem (o TickFn roda numa goroutine só; handlers de clients
+// diferentes podem rodar em paralelo).
+func (s *Server[T, M]) Rand() *rand.Rand {
+	return s.rand
+}
+
+// reseedForRecording troca a semente do Rand por uma derivada da sequência
+// atual e a retorna, para a gravação começar de um estado conhecido
+func (s *Server[T, M]) reseedForRecording() int64 {
+	seed := s.randSrc.Int63()
+	s.randSrc.Seed(seed)
+	return seed
+}
+
+func seedRecordData(seed int64) []byte {
+	return strconv.AppendInt(nil, seed, 10)
+}
+
+// replaySeed aplica a semente gravada ao Rand do servidor do Replay
+func (s *Server[T, M]) replaySeed(data []byte) error {
+	seed, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return err
+	}
+	s.randSrc.Seed(seed)
+	return nil
+}
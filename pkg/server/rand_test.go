@@ -0,0 +1,89 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// draws retorna os próximos n valores do Rand do servidor
+func draws[T, M any](s *Server[T, M], n int) []int64 {
+	vals := make([]int64, n)
+	for i := range vals {
+		vals[i] = s.Rand().Int63()
+	}
+	return vals
+}
+
+func TestRandSeedIsReproducible(t *testing.T) {
+	newSeeded := func(seed int64) *Server[*Client, *Message] {
+		s, err := NewTestServer(NewClient, NewMessage, WithRandSeed(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	a, b := draws(newSeeded(42), 10), draws(newSeeded(42), 10)
+	if !slices.Equal(a, b) {
+		t.Fatalf("same seed gave different sequences:\n%v\n%v", a, b)
+	}
+	if c := draws(newSeeded(43), 10); slices.Equal(a, c) {
+		t.Fatal("different seeds gave the same sequence")
+	}
+}
+
+func TestRecordedSeedReplays(t *testing.T) {
+	const rolls = 5
+	// roller registra um handler que sorteia um valor por mensagem "roll"
+	roller := func(s *Server[*Client, *Message]) func() []int64 {
+		var mu sync.Mutex
+		var got []int64
+		s.Handle("roll", func(context.Context, *Client, *Message) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, s.Rand().Int63())
+		})
+		return func() []int64 {
+			mu.Lock()
+			defer mu.Unlock()
+			return slices.Clone(got)
+		}
+	}
+
+	live, err := NewTestServer(NewClient, NewMessage, WithFraming(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	liveRolls := roller(live)
+	live.Start()
+	t.Cleanup(live.Stop)
+
+	var log bytes.Buffer
+	live.StartRecording(&log)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc := connectTestClients(t, ctx, live, 1)[0]
+	for i := 0; i < rolls; i++ {
+		if err := tc.Send(&Message{Type: "roll"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor(t, ctx, func() bool { return len(liveRolls()) == rolls })
+	live.StopRecording()
+
+	// O servidor do Replay parte de outra semente: só a gravada reproduz os sorteios
+	replay, err := NewTestServer(NewClient, NewMessage, WithFraming(true), WithRandSeed(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayRolls := roller(replay)
+	if err := Replay(&log, replay, false); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := liveRolls(), replayRolls(); !slices.Equal(want, got) {
+		t.Fatalf("replayed rolls = %v, want %v", got, want)
+	}
+}
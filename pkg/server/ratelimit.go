@@ -0,0 +1,74 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy define o que acontece quando um client excede o limite de mensagens
+type RateLimitPolicy int
+
+const (
+	// RateLimitDrop descarta a mensagem excedente
+	RateLimitDrop RateLimitPolicy = iota
+	// RateLimitDisconnect encerra a conexão do client
+	RateLimitDisconnect
+)
+
+// tokenBucket implementa um limitador token-bucket simples
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(perSecond, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = perSecond
+	}
+	return &tokenBucket{
+		rate:   float64(perSecond),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) allow() bool {
+	return b.allowN(1)
+}
+
+// allowN consome n tokens se houver saldo suficiente
+func (b *tokenBucket) allowN(n float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens < n {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// allowMessage desconta uma mensagem do limite do client (WithMessageRateLimit).
+// É chamado para cada frame, stream ou datagrama recebido antes da
+// decodificação, para que payloads malformados também sejam limitados.
+func (s *Server[T, M]) allowMessage(conn *Conn, c T) bool {
+	if conn.limiter == nil || conn.limiter.allow() {
+		return true
+	}
+	if s.OnRateLimited != nil {
+		s.OnRateLimited(c)
+	}
+	if s.opts.rateLimitPolicy == RateLimitDisconnect {
+		conn.closeWithReason(ReasonKicked, CloseCodeRateLimited, ErrRateLimited.Error())
+	}
+	return false
+}
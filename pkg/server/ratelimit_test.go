@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimitChargesMalformedMessages(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithMessageRateLimit(1, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	malformed := make(chan struct{}, 2)
+	limited := make(chan struct{}, 1)
+	s.OnMalformedMessage = func(*Client, []byte, error) { malformed <- struct{}{} }
+	s.OnRateLimited = func(*Client) { limited <- struct{}{} }
+	s.OnMsg = func(context.Context, *Client, *Message) { t.Error("rate limited message was delivered") }
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc := connectTestClients(t, ctx, s, 1)[0]
+
+	// Os payloads malformados consomem o burst antes da decodificação
+	for i := 0; i < 2; i++ {
+		str, err := tc.Conn.OpenStream()
+		if err != nil {
+			t.Fatal(err)
+		}
+		str.Write([]byte("not json"))
+		str.Close()
+		select {
+		case <-malformed:
+		case <-ctx.Done():
+			t.Fatal("malformed message was not reported")
+		}
+	}
+
+	if err := tc.Send(&Message{Type: "move"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-limited:
+	case <-ctx.Done():
+		t.Fatal("OnRateLimited was not called")
+	}
+	if n := s.Stats().MessagesByType["move"]; n != 0 {
+		t.Fatalf("MessagesByType[move] = %d for a dropped message", n)
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrReadTimeout indica uma stream de entrada que não completou a mensagem no prazo de WithReadTimeout
+var ErrReadTimeout = errors.New("read timeout")
+
+// WithReadTimeout limita o tempo para o client completar uma mensagem numa
+// stream (0 = sem limite). Sem framing o prazo vale para a stream inteira; com
+// framing ele vale para o primeiro frame e, depois, para terminar cada frame a
+// partir do primeiro byte, então a stream persistente pode ficar ociosa entre
+// mensagens. Streams que estouram o prazo são canceladas com StreamCodeReadTimeout.
+func WithReadTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.readTimeout = d
+	}
+}
+
+// frameDeadlineReader arma o prazo de leitura quando um frame começa a chegar
+// e o desarma quando ele termina
+type frameDeadlineReader struct {
+	stream  *Stream
+	timeout time.Duration
+	armed   bool
+}
+
+// newFrameDeadlineReader já arma o prazo para o primeiro frame
+func newFrameDeadlineReader(stream *Stream, timeout time.Duration) *frameDeadlineReader {
+	stream.SetReadDeadline(time.Now().Add(timeout))
+	return &frameDeadlineReader{stream: stream, timeout: timeout, armed: true}
+}
+
+func (r *frameDeadlineReader) Read(p []byte) (int, error) {
+	n, err := r.stream.Read(p)
+	if n > 0 && !r.armed {
+		r.stream.SetReadDeadline(time.Now().Add(r.timeout))
+		r.armed = true
+	}
+	return n, err
+}
+
+// frameDone libera a stream para ficar ociosa até o próximo frame
+func (r *frameDeadlineReader) frameDone() {
+	r.stream.SetReadDeadline(time.Time{})
+	r.armed = false
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// MessageTypeSession é usado no handshake de sessão, tanto no pedido quanto na resposta
+const MessageTypeSession = "session"
+
+// SessionRequest é o payload enviado pelo client no handshake de sessão.
+// Token vazio inicia uma sessão nova.
+type SessionRequest struct {
+	Token string `json:"token,omitempty"`
+}
+
+// SessionResponse é a resposta do servidor com o token para reconectar
+type SessionResponse struct {
+	Token   string `json:"token"`
+	Resumed bool   `json:"resumed"`
+}
+
+// ConnBinder é implementado por clients que podem ser religados a uma nova conexão
+type ConnBinder interface {
+	SetConn(conn *Conn)
+}
+
+// WithReconnectWindow habilita a retomada de sessão: a primeira mensagem de cada
+// conexão (após o auth) deve ser "session", e o client desconectado fica guardado
+// por d. Se ele voltar com o token nesse intervalo o mesmo T é reaproveitado e
+// o OnReconnect é chamado no lugar do OnConn; senão o OnDisc é chamado ao fim do prazo.
+func WithReconnectWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.reconnectWindow = d
+	}
+}
+
+// WithReconnectSecret define a chave usada para assinar os tokens de sessão. Use
+// a mesma chave entre reinícios para que tokens antigos continuem válidos
+// (padrão: chave aleatória por processo).
+func WithReconnectSecret(key []byte) Option {
+	return func(o *options) {
+		o.reconnectSecret = key
+	}
+}
+
+// heldClient é um client desconectado aguardando reconexão
+type heldClient[T any] struct {
+	client T
+	conn   *Conn
+	tags   []string
+	topics []string
+	info   DisconnectInfo
+	timer  *time.Timer
+}
+
+// heldClients guarda os clients desconectados dentro da janela de reconexão
+type heldClients[T any] struct {
+	mu      sync.Mutex
+	clients map[string]*heldClient[T]
+	// expiring conta os expireHeld em andamento, esperados pelo Stop
+	expiring sync.WaitGroup
+}
+
+// startSession faz o handshake de sessão. Retorna o client retomado e true
+// quando o token corresponde a uma sessão guardada ou ainda ativa. Cada
+// handshake consome o token recebido e responde com um novo.
+func (s *Server[T, M]) startSession(conn *Conn, client T) (T, bool, error) {
+	stream, msg, err := s.readHandshake(conn, MessageTypeSession)
+	if err != nil {
+		return client, false, err
+	}
+	defer stream.Close()
+	var req SessionRequest
+	if err := s.opts.codec.Unmarshal(msg.Data, &req); err != nil {
+		return client, false, err
+	}
+
+	resumed := false
+	ci, isClient := any(client).(ClientInterface)
+	if req.Token != "" {
+		claims, err := s.parseSessionToken(req.Token)
+		if err != nil {
+			return client, false, err
+		}
+		// Com Authenticator, só a mesma identidade pode retomar a sessão
+		if s.opts.authenticator != nil && claims.subject != authSubject(client) {
+			return client, false, errSessionIdentity
+		}
+		if err := s.consumeSessionToken(claims); err != nil {
+			return client, false, err
+		}
+		id := claims.id
+		if prev, ok := s.takeSession(id, conn); ok {
+			client, resumed = prev, true
+		} else if isClient {
+			// Sessão expirada ou de antes de um reinício: mantém o ID para que o
+			// estado restaurado por RestoreState seja aplicado
+			ci.SetID(id)
+		}
+	}
+
+	resp := SessionResponse{Resumed: resumed}
+	if c, ok := any(client).(ClientInterface); ok && c.GetID() != "" {
+		resp.Token = s.issueSessionToken(c.GetID(), authSubject(client))
+	}
+	return client, resumed, s.writeHandshake(stream, MessageTypeSession, resp)
+}
+
+// takeSession retira a sessão do ID, guardada ou ainda ativa em outra conexão,
+// e a religa à nova conexão
+func (s *Server[T, M]) takeSession(id string, conn *Conn) (T, bool) {
+	var (
+		tags   []string
+		topics []string
+		prev   *Conn
+	)
+	s.held.mu.Lock()
+	h, ok := s.held.clients[id]
+	if ok {
+		h.timer.Stop()
+		delete(s.held.clients, id)
+	}
+	s.held.mu.Unlock()
+
+	var client T
+	switch {
+	case ok:
+		client, tags, topics, prev = h.client, h.tags, h.topics, h.conn
+	default:
+		old, c, found := s.findByID(id)
+		if !found {
+			return client, false
+		}
+		if _, ok := any(c).(ConnBinder); !ok {
+			return client, false
+		}
+		// A conexão antiga sai sem OnDisc: a sessão continua nesta
+		old.superseded.Store(true)
+		s.conns.Delete(old)
+		tags = old.tagIndex().tagsOf(old)
+		topics = s.topics.patternsOf(old)
+		s.tags.removeConn(old)
+		s.topics.removeConn(old)
+		old.closeWithReason(ReasonKicked, CloseCodeSessionResumed, "session resumed elsewhere")
+		// Os handlers da conexão antiga ainda usam o client: ele só troca de
+		// conexão depois que eles terminam
+		<-old.drained
+		client, prev = c, old
+	}
+
+	b, ok := any(client).(ConnBinder)
+	if !ok {
+		return client, false
+	}
+	b.SetConn(conn)
+	if prev.seqs != nil {
+		// A janela de sequência continua valendo: a reconexão não abre brecha para replay
+		conn.seqs = prev.seqs
+	}
+	if prev.reorder != nil {
+		prev.reorder.rebind(conn)
+		conn.reorder = prev.reorder
+	}
+	for _, tag := range tags {
+		conn.tagIndex().add(conn, tag)
+	}
+	for _, pattern := range topics {
+		s.topics.add(conn, pattern)
+	}
+	return client, true
+}
+
+// findByID procura a conexão ativa do client com o ID
+func (s *Server[T, M]) findByID(id string) (*Conn, T, bool) {
+	var (
+		conn   *Conn
+		client T
+		found  bool
+	)
+	s.conns.Range(func(k *Conn, c T) bool {
+		if ci, ok := any(c).(ClientInterface); ok && ci.GetID() == id {
+			conn, client, found = k, c, true
+			return false
+		}
+		return true
+	})
+	return conn, client, found
+}
+
+// resumeClient registra o client retomado na nova conexão
+func (s *Server[T, M]) resumeClient(conn *Conn, c T) {
+	s.conns.Store(conn, c)
+	s.record(RecordConnect, conn, c, nil, 0)
+	if s.OnReconnect != nil {
+		s.OnReconnect(c)
+	}
+	s.wakeRoomTick(c)
+	s.trackRoom(conn, c)
+	s.emitClient(EventReconnect, conn, c, DisconnectInfo{})
+}
+
+// holdForReconnect guarda o client desconectado pela janela de reconexão.
+// Retorna false quando a desconexão deve seguir o fluxo normal.
+func (s *Server[T, M]) holdForReconnect(conn *Conn, c T, info DisconnectInfo) bool {
+	if s.opts.reconnectWindow <= 0 || info.Reason == ReasonKicked || info.Reason == ReasonServerShutdown {
+		return false
+	}
+	ci, ok := any(c).(ClientInterface)
+	if !ok || ci.GetID() == "" {
+		return false
+	}
+	if _, ok := any(c).(ConnBinder); !ok {
+		return false
+	}
+	id := ci.GetID()
+	h := &heldClient[T]{client: c, conn: conn, tags: conn.tagIndex().tagsOf(conn), topics: s.topics.patternsOf(conn), info: info}
+	s.conns.Delete(conn)
+	s.tags.removeConn(conn)
+	s.topics.removeConn(conn)
+
+	s.held.mu.Lock()
+	defer s.held.mu.Unlock()
+	if s.held.clients == nil {
+		s.held.clients = make(map[string]*heldClient[T])
+	}
+	h.timer = time.AfterFunc(s.opts.reconnectWindow, func() { s.expireHeld(id, h) })
+	s.held.clients[id] = h
+	return true
+}
+
+// expireHeld finaliza a desconexão de um client que não voltou a tempo
+func (s *Server[T, M]) expireHeld(id string, h *heldClient[T]) {
+	s.held.mu.Lock()
+	if s.held.clients[id] != h {
+		s.held.mu.Unlock()
+		return
+	}
+	delete(s.held.clients, id)
+	s.held.expiring.Add(1)
+	s.held.mu.Unlock()
+	defer s.held.expiring.Done()
+	s.finishDisconnect(h.conn, h.client, h.info)
+}
+
+// releaseHeld encerra todas as sessões guardadas, usado no Stop. Os prazos que
+// já expiraram terminam o OnDisc antes do retorno.
+func (s *Server[T, M]) releaseHeld() {
+	s.held.mu.Lock()
+	held := s.held.clients
+	s.held.clients = nil
+	s.held.mu.Unlock()
+	s.held.expiring.Wait()
+	for _, h := range held {
+		h.timer.Stop()
+		info := h.info
+		info.Reason = ReasonServerShutdown
+		s.finishDisconnect(h.conn, h.client, info)
+	}
+}
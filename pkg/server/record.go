@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// RecordKind identifica o tipo de evento gravado
+type RecordKind string
+
+// Tipos de evento gravados por StartRecording
+const (
+	RecordConnect    RecordKind = "connect"
+	RecordMessage    RecordKind = "message"
+	RecordDatagram   RecordKind = "datagram"
+	RecordDisconnect RecordKind = "disconnect"
+	// RecordSeed abre o log com a semente do Rand, reaplicada pelo Replay
+	RecordSeed RecordKind = "seed"
+)
+
+// Record é um evento de entrada gravado. O log é uma sequência de Records em
+// JSON, cada um com o prefixo de comprimento do framing.
+type Record struct {
+	// Time é o instante do evento relativo ao início da gravação
+	Time   time.Duration    `json:"t"`
+	Kind   RecordKind       `json:"kind"`
+	Client string           `json:"client"`
+	Data   []byte           `json:"data,omitempty"`
+	Reason DisconnectReason `json:"reason,omitempty"`
+}
+
+// recorder serializa os eventos gravados no writer
+type recorder struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+}
+
+// StartRecording grava em w toda mensagem, datagrama, conexão e desconexão
+// recebidos, com horário e ID do client. O log pode ser reproduzido com Replay.
+// O Rand recebe uma semente nova, gravada no início do log.
+func (s *Server[T, M]) StartRecording(w io.Writer) {
+	rec := &recorder{w: w, start: time.Now()}
+	b, err := json.Marshal(Record{Kind: RecordSeed, Data: seedRecordData(s.reseedForRecording())})
+	if err == nil {
+		err = writeFrame(w, b)
+	}
+	if err != nil {
+		log.Println("recording error:", err)
+		return
+	}
+	s.recorder.Store(rec)
+}
+
+// StopRecording encerra a gravação iniciada por StartRecording
+func (s *Server[T, M]) StopRecording() {
+	s.recorder.Store(nil)
+}
+
+// record grava o evento se houver uma gravação ativa
+func (s *Server[T, M]) record(kind RecordKind, conn *Conn, c T, data []byte, reason DisconnectReason) {
+	rec := s.recorder.Load()
+	if rec == nil {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	b, err := json.Marshal(Record{
+		Time:   time.Since(rec.start),
+		Kind:   kind,
+		Client: clientID(conn, c),
+		Data:   data,
+		Reason: reason,
+	})
+	if err == nil {
+		err = writeFrame(rec.w, b)
+	}
+	if err != nil {
+		log.Println("recording error:", err)
+		s.recorder.CompareAndSwap(rec, nil)
+	}
+}
+
+// errDetachedConn é retornado por operações de transporte em conexões do Replay
+var errDetachedConn = errors.New("connection has no transport")
+
+// detachedConn é o estado de uma conexão sem transporte usada pelo Replay
+type detachedConn struct {
+	addr net.Addr
+	ctx  context.Context
+}
+
+// replayAddr é o endereço das conexões do Replay, identificado pelo ID gravado
+type replayAddr string
+
+func (a replayAddr) Network() string { return "replay" }
+func (a replayAddr) String() string  { return string(a) }
+
+// replayClient é um client recriado a partir da gravação
+type replayClient[T any] struct {
+	conn   *Conn
+	client T
+}
+
+// Replay alimenta s com os eventos gravados por StartRecording, usando os mesmos
+// caminhos de tratamento das conexões reais. Os clients são recriados pelo
+// ClientFactory com o ID gravado e não têm transporte: envios para eles falham.
+// Com realtime os eventos respeitam os intervalos gravados; caso contrário são
+// processados o mais rápido possível. Clients ainda conectados no fim da
+// gravação são desconectados com ReasonServerShutdown.
+func Replay[T, M any](r io.Reader, s *Server[T, M], realtime bool) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Sem limite de chamadas: as gravadas já passaram pelo limite ao vivo
+	rpc := newStreamRPC(io.Discard, s.opts.framing, 0)
+	defer rpc.wg.Wait()
+
+	clients := make(map[string]*replayClient[T])
+	get := func(id string) *replayClient[T] {
+		if rc, ok := clients[id]; ok {
+			return rc
+		}
+		conn := s.newConn(nil)
+		conn.limiter = nil
+		conn.quota = nil
+		conn.reliable = nil
+		conn.queue = nil
+		conn.detached = &detachedConn{addr: replayAddr(id), ctx: ctx}
+		c := s.ClientFactory(conn)
+		if ci, ok := any(c).(ClientInterface); ok {
+			ci.SetID(id)
+		}
+		rc := &replayClient[T]{conn: conn, client: c}
+		clients[id] = rc
+		s.addClient(conn, c)
+		return rc
+	}
+
+	start := time.Now()
+	for {
+		data, err := readFrame(r, 0)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read record: %w", err)
+		}
+		var rec Record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("decode record: %w", err)
+		}
+		if realtime {
+			time.Sleep(time.Until(start.Add(rec.Time)))
+		}
+
+		switch rec.Kind {
+		case RecordSeed:
+			if err := s.replaySeed(rec.Data); err != nil {
+				return fmt.Errorf("decode seed record: %w", err)
+			}
+		case RecordConnect:
+			get(rec.Client)
+		case RecordMessage:
+			rc := get(rec.Client)
+			s.handleData(ctx, rc.conn, rc.client, rpc, rec.Data)
+		case RecordDatagram:
+			rc := get(rec.Client)
+			if s.OnDatagram != nil {
+				s.OnDatagram(rc.client, rec.Data)
+			}
+		case RecordDisconnect:
+			if rc, ok := clients[rec.Client]; ok {
+				s.removeClient(rc.conn, rc.client, DisconnectInfo{Reason: rec.Reason})
+				delete(clients, rec.Client)
+			}
+		}
+	}
+
+	for _, rc := range clients {
+		s.removeClient(rc.conn, rc.client, DisconnectInfo{Reason: ReasonServerShutdown})
+	}
+	return nil
+}
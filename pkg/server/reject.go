@@ -0,0 +1,64 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/webtransport-go"
+)
+
+// ErrRejected indica uma conexão recusada pelo OnAccept com um erro comum
+var ErrRejected = errors.New("connection rejected")
+
+// RejectError recusa a conexão no OnAccept com o código e o motivo que o client
+// recebe no fechamento. Use os códigos padrão (CloseCodeServerFull,
+// CloseCodeBanned, CloseCodeAuthFailed, CloseCodeRejected) ou um próprio.
+type RejectError struct {
+	Code   quic.ApplicationErrorCode
+	Reason string
+}
+
+func (e *RejectError) Error() string {
+	return fmt.Sprintf("connection rejected (code %#x): %s", uint64(e.Code), e.Reason)
+}
+
+// Is faz o RejectError casar com o erro do código padrão, ex.: ErrBanned para CloseCodeBanned
+func (e *RejectError) Is(target error) bool {
+	sentinel, ok := closeCodeErrors[e.Code]
+	return ok && sentinel == target
+}
+
+// RejectErrorFrom extrai do erro de uma conexão fechada pelo servidor o código
+// e o motivo recebidos, para o client mostrar uma mensagem (ex.: "banned until
+// 18:00"). Retorna false se a conexão não foi fechada pelo outro lado com um código.
+func RejectErrorFrom(err error) (*RejectError, bool) {
+	var appErr *quic.ApplicationError
+	if errors.As(err, &appErr) && appErr.Remote {
+		return &RejectError{Code: appErr.ErrorCode, Reason: appErr.ErrorMessage}, true
+	}
+	var sessErr *webtransport.SessionError
+	if errors.As(err, &sessErr) && sessErr.Remote {
+		return &RejectError{Code: quic.ApplicationErrorCode(sessErr.ErrorCode), Reason: sessErr.Message}, true
+	}
+	return nil, false
+}
+
+// accept aplica o OnAccept; um erro que não seja RejectError vira CloseCodeRejected
+func (s *Server[T, M]) accept(conn *Conn, c T) bool {
+	if s.OnAccept == nil {
+		return true
+	}
+	err := s.OnAccept(c)
+	if err == nil {
+		return true
+	}
+	var rej *RejectError
+	if !errors.As(err, &rej) {
+		rej = &RejectError{Code: CloseCodeRejected, Reason: err.Error()}
+	}
+	log.Printf("rejected connection from %s: %v\n", conn.RemoteAddr(), rej)
+	conn.closeWithReason(ReasonKicked, rej.Code, rej.Reason)
+	return false
+}
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Formato dos datagramas confiáveis:
+//
+//	dados: [reliableMagic][reliableKindData][seq uint32][base uint32]payload
+//	ack:   [reliableMagic][reliableKindAck][next uint32][mask uint32]
+//
+// base é a menor sequência que o remetente ainda retransmite: as anteriores
+// chegaram ou foram abandonadas, e o destinatário deixa de esperar por elas.
+// next é a próxima sequência esperada (todas as anteriores chegaram) e o bit i
+// de mask indica que a sequência next+1+i também chegou.
+const (
+	reliableMagic      byte = 0xFE
+	reliableKindData   byte = 1
+	reliableKindAck    byte = 2
+	reliableHeaderSize      = 10
+	reliableAckSize         = 10
+)
+
+const (
+	// DefaultReliableWindow é o número padrão de datagramas confiáveis aguardando ack
+	DefaultReliableWindow = 256
+	// DefaultReliableRetransmit é o intervalo padrão entre retransmissões
+	DefaultReliableRetransmit = 100 * time.Millisecond
+	// DefaultReliableMaxRetries é o número padrão de retransmissões antes de desistir
+	DefaultReliableMaxRetries = 10
+)
+
+var (
+	// ErrReliableWindowFull indica que há datagramas demais aguardando ack
+	ErrReliableWindowFull = errors.New("reliable datagram window full")
+	// ErrReliableDatagramsDisabled indica que WithReliableDatagrams não foi configurado
+	ErrReliableDatagramsDisabled = errors.New("reliable datagrams disabled")
+)
+
+// DatagramConn é uma conexão capaz de enviar e receber datagramas
+type DatagramConn interface {
+	SendDatagram(data []byte) error
+	ReceiveDatagram(ctx context.Context) ([]byte, error)
+}
+
+type pendingDatagram struct {
+	pkt    []byte
+	sentAt time.Time
+	tries  int
+}
+
+// reliableLink implementa sequência, acks seletivos e retransmissão sobre datagramas
+type reliableLink struct {
+	send       func([]byte) error
+	window     int
+	retransmit time.Duration
+	maxRetries int
+
+	mu       sync.Mutex
+	nextSeq  uint32
+	pending  map[uint32]*pendingDatagram
+	recvNext uint32
+	received map[uint32]struct{}
+}
+
+func newReliableLink(send func([]byte) error, window int) *reliableLink {
+	if window <= 0 {
+		window = DefaultReliableWindow
+	}
+	return &reliableLink{
+		send:       send,
+		window:     window,
+		retransmit: DefaultReliableRetransmit,
+		maxRetries: DefaultReliableMaxRetries,
+		pending:    make(map[uint32]*pendingDatagram),
+		received:   make(map[uint32]struct{}),
+	}
+}
+
+// Send envia o payload com número de sequência e o mantém até receber ack
+func (l *reliableLink) Send(data []byte) error {
+	l.mu.Lock()
+	if len(l.pending) >= l.window {
+		l.mu.Unlock()
+		return ErrReliableWindowFull
+	}
+	pkt := make([]byte, reliableHeaderSize+len(data))
+	pkt[0], pkt[1] = reliableMagic, reliableKindData
+	binary.BigEndian.PutUint32(pkt[2:], l.nextSeq)
+	copy(pkt[reliableHeaderSize:], data)
+	seq := l.nextSeq
+	l.nextSeq++
+	l.pending[seq] = &pendingDatagram{pkt: pkt, sentAt: time.Now()}
+	binary.BigEndian.PutUint32(pkt[6:], l.base())
+	l.mu.Unlock()
+
+	if err := l.send(pkt); err != nil {
+		l.mu.Lock()
+		delete(l.pending, seq)
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// handle processa um datagrama recebido. Retorna o payload a ser entregue à
+// aplicação e false quando o datagrama era um ack ou uma duplicata.
+func (l *reliableLink) handle(pkt []byte) ([]byte, bool) {
+	if len(pkt) < 2 || pkt[0] != reliableMagic {
+		return pkt, true
+	}
+	switch pkt[1] {
+	case reliableKindAck:
+		if len(pkt) >= reliableAckSize {
+			l.handleAck(binary.BigEndian.Uint32(pkt[2:]), binary.BigEndian.Uint32(pkt[6:]))
+		}
+		return nil, false
+	case reliableKindData:
+		if len(pkt) < reliableHeaderSize {
+			return nil, false
+		}
+		fresh, ack := l.receive(binary.BigEndian.Uint32(pkt[2:]), binary.BigEndian.Uint32(pkt[6:]))
+		if err := l.send(ack); err != nil {
+			log.Println("reliable datagram ack error:", err)
+		}
+		if !fresh {
+			return nil, false
+		}
+		return pkt[reliableHeaderSize:], true
+	}
+	return pkt, true
+}
+
+// base retorna a menor sequência pendente, ou a próxima a ser enviada.
+// Deve ser chamado com mu travado.
+func (l *reliableLink) base() uint32 {
+	base := l.nextSeq
+	for seq := range l.pending {
+		if int32(seq-base) < 0 {
+			base = seq
+		}
+	}
+	return base
+}
+
+// receive registra a sequência recebida e monta o ack correspondente. Se o
+// remetente abandonou sequências que não chegaram (base à frente de recvNext),
+// a espera por elas é descartada.
+func (l *reliableLink) receive(seq, base uint32) (fresh bool, ack []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if int32(base-l.recvNext) > 0 {
+		l.recvNext = base
+		for s := range l.received {
+			if int32(s-base) < 0 {
+				delete(l.received, s)
+			}
+		}
+		l.advance()
+	}
+	ahead := int32(seq - l.recvNext)
+	if ahead >= 0 && int(ahead) < 2*l.window {
+		if _, dup := l.received[seq]; !dup {
+			fresh = true
+			l.received[seq] = struct{}{}
+			l.advance()
+		}
+	}
+
+	var mask uint32
+	for i := uint32(0); i < 32; i++ {
+		if _, ok := l.received[l.recvNext+1+i]; ok {
+			mask |= 1 << i
+		}
+	}
+	ack = make([]byte, reliableAckSize)
+	ack[0], ack[1] = reliableMagic, reliableKindAck
+	binary.BigEndian.PutUint32(ack[2:], l.recvNext)
+	binary.BigEndian.PutUint32(ack[6:], mask)
+	return fresh, ack
+}
+
+// advance avança recvNext pelas sequências contíguas já recebidas
+func (l *reliableLink) advance() {
+	for {
+		if _, ok := l.received[l.recvNext]; !ok {
+			return
+		}
+		delete(l.received, l.recvNext)
+		l.recvNext++
+	}
+}
+
+func (l *reliableLink) handleAck(next, mask uint32) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for seq := range l.pending {
+		d := int32(seq - next)
+		if d < 0 || (d >= 1 && d <= 32 && mask&(1<<(d-1)) != 0) {
+			delete(l.pending, seq)
+		}
+	}
+}
+
+// run retransmite os datagramas sem ack até o contexto terminar
+func (l *reliableLink) run(ctx context.Context) {
+	ticker := time.NewTicker(l.retransmit / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, pkt := range l.due(now) {
+				if err := l.send(pkt); err != nil {
+					log.Println("reliable datagram retransmit error:", err)
+				}
+			}
+		}
+	}
+}
+
+// due retorna os pacotes a retransmitir e descarta os que esgotaram as
+// tentativas. As retransmissões levam a base atual, para o destinatário
+// também saber dos abandonos sem esperar um envio novo.
+func (l *reliableLink) due(now time.Time) [][]byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []*pendingDatagram
+	for seq, p := range l.pending {
+		if now.Sub(p.sentAt) < l.retransmit {
+			continue
+		}
+		if p.tries >= l.maxRetries {
+			delete(l.pending, seq)
+			log.Printf("reliable datagram %d dropped after %d retries\n", seq, p.tries)
+			continue
+		}
+		p.tries++
+		p.sentAt = now
+		out = append(out, p)
+	}
+	base := l.base()
+	pkts := make([][]byte, len(out))
+	for i, p := range out {
+		// Cópia: o Send do pacote anterior pode estar em andamento fora do lock
+		pkt := append([]byte(nil), p.pkt...)
+		binary.BigEndian.PutUint32(pkt[6:], base)
+		pkts[i] = pkt
+	}
+	return pkts
+}
+
+// ReliableDatagrams é o lado client da camada de datagramas confiáveis
+type ReliableDatagrams struct {
+	conn DatagramConn
+	link *reliableLink
+}
+
+// NewReliableDatagrams cria a camada confiável sobre a conexão. As retransmissões
+// rodam até ctx terminar; window limita os datagramas aguardando ack (0 = padrão).
+func NewReliableDatagrams(ctx context.Context, conn DatagramConn, window int) *ReliableDatagrams {
+	r := &ReliableDatagrams{conn: conn, link: newReliableLink(conn.SendDatagram, window)}
+	go r.link.run(ctx)
+	return r
+}
+
+// Send envia dados com entrega confiável
+func (r *ReliableDatagrams) Send(data []byte) error {
+	return r.link.Send(data)
+}
+
+// Receive retorna o próximo datagrama recebido, já sem duplicatas e acks.
+// Datagramas comuns são retornados sem alteração.
+func (r *ReliableDatagrams) Receive(ctx context.Context) ([]byte, error) {
+	for {
+		pkt, err := r.conn.ReceiveDatagram(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if data, ok := r.link.handle(pkt); ok {
+			return data, nil
+		}
+	}
+}
+
+// WithReliableDatagrams habilita a camada de datagramas confiáveis usada por
+// SendReliableDatagram. window limita os datagramas aguardando ack por client.
+// Com a camada ativa, datagramas recebidos que comecem com 0xFE são reservados.
+func WithReliableDatagrams(window int) Option {
+	return func(o *options) {
+		if window <= 0 {
+			window = DefaultReliableWindow
+		}
+		o.reliableWindow = window
+	}
+}
+
+// SendReliableDatagram envia dados por datagrama com ack e retransmissão.
+// A entrega não é ordenada e o envio é abandonado após DefaultReliableMaxRetries.
+func (s *Server[T, M]) SendReliableDatagram(c T, data []byte) error {
+	conn, err := s.connOf(c)
+	if err != nil {
+		return err
+	}
+	if conn.reliable == nil {
+		return ErrReliableDatagramsDisabled
+	}
+	return conn.reliable.Send(data)
+}
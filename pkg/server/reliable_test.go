@@ -0,0 +1,112 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// lossyPair liga dois reliableLinks diretamente; drop decide, por pacote, se
+// ele se perde no caminho
+type lossyPair struct {
+	a, b      *reliableLink
+	drop      func(pkt []byte) bool
+	delivered map[string]int
+}
+
+func newLossyPair(window int, drop func(pkt []byte) bool) *lossyPair {
+	p := &lossyPair{drop: drop, delivered: make(map[string]int)}
+	p.a = newReliableLink(func(pkt []byte) error { p.transmit(p.b, pkt, true); return nil }, window)
+	p.b = newReliableLink(func(pkt []byte) error { p.transmit(p.a, pkt, false); return nil }, window)
+	return p
+}
+
+func (p *lossyPair) transmit(to *reliableLink, pkt []byte, toB bool) {
+	if p.drop(pkt) {
+		return
+	}
+	data, ok := to.handle(pkt)
+	if ok && toB {
+		p.delivered[string(data)]++
+	}
+}
+
+// settle roda as retransmissões de a com um relógio simulado até não haver pendências
+func (p *lossyPair) settle(t *testing.T, now time.Time) time.Time {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		p.a.mu.Lock()
+		n := len(p.a.pending)
+		p.a.mu.Unlock()
+		if n == 0 {
+			return now
+		}
+		now = now.Add(p.a.retransmit)
+		for _, pkt := range p.a.due(now) {
+			p.a.send(pkt)
+		}
+	}
+	t.Fatal("pending datagrams never settled")
+	return now
+}
+
+func TestReliableDatagramsEventualDelivery(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	p := newLossyPair(64, func([]byte) bool { return rng.Float64() < 0.3 })
+	now := time.Now()
+	const total = 200
+	for i := 0; i < total; i++ {
+		if err := p.a.Send([]byte(fmt.Sprint(i))); err != nil {
+			now = p.settle(t, now)
+			if err := p.a.Send([]byte(fmt.Sprint(i))); err != nil {
+				t.Fatalf("send %d: %v", i, err)
+			}
+		}
+	}
+	p.settle(t, now)
+
+	for i := 0; i < total; i++ {
+		if n := p.delivered[fmt.Sprint(i)]; n != 1 {
+			t.Fatalf("payload %d delivered %d times, want 1", i, n)
+		}
+	}
+}
+
+func TestReliableDatagramsResyncAfterGiveUp(t *testing.T) {
+	const window = 4
+	lost := true
+	p := newLossyPair(window, func(pkt []byte) bool {
+		return lost && pkt[1] == reliableKindData
+	})
+	now := time.Now()
+	// Mais perdas do que 2*window: sem o aviso de abandono o destinatário
+	// descartaria para sempre as sequências novas
+	for round := 0; round < 4; round++ {
+		for i := 0; i < window; i++ {
+			if err := p.a.Send([]byte("lost")); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+		}
+		now = p.settle(t, now)
+	}
+	if p.delivered["lost"] != 0 {
+		t.Fatalf("lost datagrams were delivered")
+	}
+
+	lost = false
+	for i := 0; i < window; i++ {
+		if err := p.a.Send([]byte(fmt.Sprint("after-", i))); err != nil {
+			t.Fatalf("send after recovery: %v", err)
+		}
+	}
+	p.settle(t, now)
+	for i := 0; i < window; i++ {
+		if n := p.delivered[fmt.Sprint("after-", i)]; n != 1 {
+			t.Fatalf("payload after-%d delivered %d times, want 1", i, n)
+		}
+	}
+	if p.b.recvNext != p.a.nextSeq {
+		t.Fatalf("receiver expects seq %d, sender is at %d", p.b.recvNext, p.a.nextSeq)
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// WithReorderBuffer entrega as mensagens com Seq na ordem da sequência,
+// segurando até size mensagens adiantadas por client. Se a sequência esperada
+// não chegar em timeout (ou o buffer encher), a lacuna é pulada e reportada ao
+// OnSequenceGap; mensagens que chegarem depois disso são descartadas. A
+// sequência de cada client começa em 1 e mensagens sem Seq não são reordenadas.
+func WithReorderBuffer(size int, timeout time.Duration) Option {
+	return func(o *options) {
+		o.reorderSize = size
+		o.reorderTimeout = timeout
+	}
+}
+
+// reorderBuffer segura as mensagens adiantadas de uma conexão até a sequência esperada chegar
+type reorderBuffer struct {
+	mu      sync.Mutex
+	conn    *Conn
+	size    int
+	timeout time.Duration
+	next    uint64
+	pending map[uint64]func()
+	timer   *time.Timer
+	gen     uint64
+	onGap   func(from, to uint64)
+}
+
+func newReorderBuffer(conn *Conn, size int, timeout time.Duration) *reorderBuffer {
+	return &reorderBuffer{
+		conn:    conn,
+		size:    size,
+		timeout: timeout,
+		next:    1,
+		pending: make(map[uint64]func()),
+	}
+}
+
+// push entrega a mensagem seq, ou a segura até as anteriores chegarem.
+// As entregas acontecem com o buffer travado, preservando a ordem.
+func (b *reorderBuffer) push(seq uint64, deliver func(), onGap func(from, to uint64)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onGap = onGap
+	if seq < b.next {
+		return
+	}
+	if _, dup := b.pending[seq]; dup {
+		return
+	}
+	b.pending[seq] = deliver
+	before := b.next
+	if len(b.pending) > b.size {
+		b.skipGap()
+	}
+	b.drain()
+	if b.next != before && b.timer != nil {
+		// A lacuna anterior foi resolvida; o prazo recomeça para a próxima
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.arm()
+}
+
+// drain entrega as mensagens consecutivas a partir de next
+func (b *reorderBuffer) drain() {
+	for {
+		deliver, ok := b.pending[b.next]
+		if !ok {
+			return
+		}
+		delete(b.pending, b.next)
+		b.next++
+		deliver()
+	}
+}
+
+// skipGap avança next até a menor sequência segura, reportando a lacuna
+func (b *reorderBuffer) skipGap() {
+	first := uint64(0)
+	for seq := range b.pending {
+		if first == 0 || seq < first {
+			first = seq
+		}
+	}
+	if first == 0 || first <= b.next {
+		return
+	}
+	if b.onGap != nil {
+		b.onGap(b.next, first-1)
+	}
+	b.next = first
+}
+
+// arm agenda o timeout da lacuna atual enquanto houver mensagens seguradas
+func (b *reorderBuffer) arm() {
+	if len(b.pending) == 0 {
+		if b.timer != nil {
+			b.timer.Stop()
+			b.timer = nil
+		}
+		return
+	}
+	if b.timer == nil {
+		b.gen++
+		gen := b.gen
+		b.timer = time.AfterFunc(b.timeout, func() { b.expire(gen) })
+	}
+}
+
+func (b *reorderBuffer) expire(gen uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen != b.gen || b.timer == nil {
+		// Timer substituído enquanto aguardava a trava
+		return
+	}
+	b.timer = nil
+	if b.conn.Context().Err() != nil {
+		return
+	}
+	b.skipGap()
+	b.drain()
+	b.arm()
+}
+
+// rebind passa o buffer para a nova conexão de uma sessão retomada
+func (b *reorderBuffer) rebind(conn *Conn) {
+	b.mu.Lock()
+	b.conn = conn
+	b.mu.Unlock()
+}
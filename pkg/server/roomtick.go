@@ -0,0 +1,115 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// RoomTickFn é chamada a cada tick de uma sala com os clients que estão nela
+type RoomTickFn[T any] func(room string, members []T)
+
+// roomTick é o loop de tick registrado para uma sala
+type roomTick[T any] struct {
+	rate    int
+	fn      RoomTickFn[T]
+	running bool
+}
+
+// roomTicks guarda os loops por sala
+type roomTicks[T any] struct {
+	mu    sync.Mutex
+	rooms map[string]*roomTick[T]
+}
+
+// SetRoomTick registra um tick próprio para a sala, com rate ticks por segundo.
+// O loop começa quando a sala ganha membros (clients que implementam RoomMember)
+// e para quando ela esvazia; a sala é verificada quando um client conecta e
+// depois de cada mensagem processada. fn nil remove o tick da sala.
+func (s *Server[T, M]) SetRoomTick(room string, rate int, fn RoomTickFn[T]) {
+	s.roomTicks.mu.Lock()
+	defer s.roomTicks.mu.Unlock()
+	if fn == nil || rate <= 0 {
+		delete(s.roomTicks.rooms, room)
+		return
+	}
+	if s.roomTicks.rooms == nil {
+		s.roomTicks.rooms = make(map[string]*roomTick[T])
+	}
+	rt, ok := s.roomTicks.rooms[room]
+	if !ok {
+		rt = &roomTick[T]{}
+		s.roomTicks.rooms[room] = rt
+	}
+	rt.rate, rt.fn = rate, fn
+	if !rt.running && s.ctx != nil && len(s.roomMembers(room)) > 0 {
+		s.startRoomTick(room, rt)
+	}
+}
+
+// wakeRoomTick inicia o loop da sala do client se ela tiver tick registrado e estiver parada
+func (s *Server[T, M]) wakeRoomTick(c T) {
+	r, ok := any(c).(RoomMember)
+	if !ok {
+		return
+	}
+	room := r.GetRoom()
+	s.roomTicks.mu.Lock()
+	defer s.roomTicks.mu.Unlock()
+	if rt, ok := s.roomTicks.rooms[room]; ok && !rt.running && s.ctx != nil {
+		s.startRoomTick(room, rt)
+	}
+}
+
+// startRoomTick deve ser chamado com roomTicks.mu travado
+func (s *Server[T, M]) startRoomTick(room string, rt *roomTick[T]) {
+	rt.running = true
+	s.wg.Add(1)
+	go s.roomTickLoop(room, rt)
+}
+
+func (s *Server[T, M]) roomTickLoop(room string, rt *roomTick[T]) {
+	defer s.wg.Done()
+	rate := rt.rate
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.roomTicks.mu.Lock()
+			rt.running = false
+			s.roomTicks.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+
+		// Os membros são lidos com a trava para que um client entrando na sala
+		// enquanto ela esvazia encontre o loop parado e o reinicie
+		s.roomTicks.mu.Lock()
+		members := s.roomMembers(room)
+		current := s.roomTicks.rooms[room] == rt
+		if len(members) == 0 || !current {
+			rt.running = false
+			s.roomTicks.mu.Unlock()
+			return
+		}
+		fn := rt.fn
+		if rt.rate != rate {
+			rate = rt.rate
+			ticker.Reset(time.Second / time.Duration(rate))
+		}
+		s.roomTicks.mu.Unlock()
+		fn(room, members)
+	}
+}
+
+// roomMembers retorna os clients da sala
+func (s *Server[T, M]) roomMembers(room string) []T {
+	var members []T
+	s.conns.Range(func(_ *Conn, c T) bool {
+		if r, ok := any(c).(RoomMember); ok && r.GetRoom() == room {
+			members = append(members, c)
+		}
+		return true
+	})
+	return members
+}
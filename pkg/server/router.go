@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"log"
+)
+
+// MessageTypeError é o tipo da resposta enviada quando o servidor não
+// consegue tratar uma mensagem
+const MessageTypeError = "error"
+
+// ErrorReply é o payload de MessageTypeError
+type ErrorReply struct {
+	// Type é o tipo da mensagem recusada
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// Handle registra um handler para um tipo de mensagem específico.
+// Mensagens sem handler registrado vão para o OnUnhandled, se definido, e
+// senão para o OnMsg. Sem nenhum dos dois o client recebe um MessageTypeError.
+func (s *Server[T, M]) Handle(msgType string, h OnMessageFn[T, M]) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	if s.handlers == nil {
+		s.handlers = make(map[string]OnMessageFn[T, M])
+	}
+	s.handlers[msgType] = h
+}
+
+// dispatch roteia a mensagem pelo tipo do envelope
+func (s *Server[T, M]) dispatch(ctx context.Context, conn *Conn, c T, msgType string, msg M) {
+	s.route(ctx, conn, c, msgType, msg)
+	s.afterDispatch(conn, c)
+}
+
+// route chama o handler do tipo da mensagem
+func (s *Server[T, M]) route(ctx context.Context, conn *Conn, c T, msgType string, msg M) {
+	s.handlersMu.RLock()
+	h, ok := s.handlers[msgType]
+	s.handlersMu.RUnlock()
+	switch {
+	case ok:
+		h(ctx, c, msg)
+	case s.OnUnhandled != nil:
+		s.OnUnhandled(ctx, c, msg)
+	case s.OnMsg != nil:
+		s.OnMsg(ctx, c, msg)
+	default:
+		s.replyError(conn, msgType, "unknown message type")
+	}
+}
+
+// afterDispatch atualiza a sala do client depois que o handler retornou
+func (s *Server[T, M]) afterDispatch(conn *Conn, c T) {
+	// O handler pode ter levado o client para outra sala, talvez com tick próprio
+	s.wakeRoomTick(c)
+	s.trackRoom(conn, c)
+}
+
+// replyError envia ao client um MessageTypeError sobre a mensagem msgType
+func (s *Server[T, M]) replyError(conn *Conn, msgType, reason string) {
+	data, err := s.encode(MessageTypeError, ErrorReply{Type: msgType, Message: reason})
+	if err != nil {
+		log.Println("error reply encode error:", err)
+		return
+	}
+	if err := conn.sendRaw(data); err != nil {
+		log.Println("error reply send error:", err)
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRouterDispatch(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routed := make(chan string, 8)
+	s.Handle("move", func(_ context.Context, _ *Client, msg *Message) { routed <- "handle:" + msg.Type })
+	s.OnMsg = func(_ context.Context, _ *Client, msg *Message) { routed <- "msg:" + msg.Type }
+	s.OnUnhandled = func(_ context.Context, _ *Client, msg *Message) { routed <- "unhandled:" + msg.Type }
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc, err := NewTestClient(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tc.Close()
+
+	for _, tt := range []struct{ msgType, want string }{
+		{"move", "handle:move"},
+		{"chat", "unhandled:chat"},
+	} {
+		if err := tc.Send(&Message{Type: tt.msgType}); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-routed:
+			if got != tt.want {
+				t.Fatalf("%s routed to %q, want %q", tt.msgType, got, tt.want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("%s was not routed", tt.msgType)
+		}
+	}
+
+	// Sem OnUnhandled o OnMsg volta a receber os tipos sem handler
+	s.OnUnhandled = nil
+	if err := tc.Send(&Message{Type: "chat"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-routed:
+		if got != "msg:chat" {
+			t.Fatalf("chat routed to %q, want msg:chat", got)
+		}
+	case <-ctx.Done():
+		t.Fatal("chat was not routed")
+	}
+}
+
+func TestRouterUnknownTypeReply(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Handle("move", func(context.Context, *Client, *Message) {})
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc, err := NewTestClient(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tc.Close()
+
+	if err := tc.Send(&Message{Type: "chat"}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := tc.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != MessageTypeError {
+		t.Fatalf("reply type = %q, want %q", msg.Type, MessageTypeError)
+	}
+	reply, err := Decode[ErrorReply](msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Type != "chat" || reply.Message != "unknown message type" {
+		t.Fatalf("reply = %+v", reply)
+	}
+}
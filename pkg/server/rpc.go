@@ -0,0 +1,306 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/quic-go/quic-go"
+)
+
+// Tipos de mensagem usados pela camada de RPC
+const (
+	MessageTypeRPCCall   = "rpc_call"
+	MessageTypeRPCResult = "rpc_result"
+	MessageTypeRPCCancel = "rpc_cancel"
+)
+
+// DefaultMaxRPCCalls é o limite padrão de chamadas RPC simultâneas por stream
+const DefaultMaxRPCCalls = 64
+
+// WithMaxRPCCalls limita as chamadas RPC em andamento em cada stream com
+// framing (padrão: DefaultMaxRPCCalls). Chamadas além do limite recebem erro
+// sem executar o handler.
+func WithMaxRPCCalls(n int) Option {
+	return func(o *options) {
+		o.maxRPCCalls = n
+	}
+}
+
+// RPCRequest é o payload de uma chamada RPC
+type RPCRequest struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// RPCResponse é o payload da resposta de uma chamada RPC
+type RPCResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// RPCError é o erro retornado pelo handler remoto
+type RPCError struct {
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return "rpc: " + e.Message
+}
+
+// RPCHandler processa uma chamada RPC e retorna o resultado a ser serializado
+type RPCHandler[T any] func(ctx context.Context, c T, params json.RawMessage) (any, error)
+
+// RegisterRPC registra um método RPC. Chamadas são multiplexadas sobre uma
+// stream bidirecional usando o ID de correlação; com framing habilitado várias
+// chamadas concorrentes, até WithMaxRPCCalls, podem compartilhar a mesma
+// stream. Um ID ainda em andamento na stream é recusado com erro.
+func (s *Server[T, M]) RegisterRPC(method string, h RPCHandler[T]) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	if s.rpcHandlers == nil {
+		s.rpcHandlers = make(map[string]RPCHandler[T])
+	}
+	s.rpcHandlers[method] = h
+}
+
+// streamRPC guarda o estado das chamadas RPC em andamento em uma stream
+type streamRPC struct {
+	stream io.Writer
+	framed bool
+
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	calls map[uint64]context.CancelFunc
+	// limit é o máximo de chamadas em calls (0 = sem limite)
+	limit int
+}
+
+func newStreamRPC(stream io.Writer, framed bool, limit int) *streamRPC {
+	return &streamRPC{stream: stream, framed: framed, calls: make(map[uint64]context.CancelFunc), limit: limit}
+}
+
+// start registra a chamada id; recusa IDs já em andamento e chamadas além do limite
+func (r *streamRPC) start(id uint64, cancel context.CancelFunc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.calls[id]; ok {
+		return fmt.Errorf("call id %d already in progress", id)
+	}
+	if r.limit > 0 && len(r.calls) >= r.limit {
+		return fmt.Errorf("too many concurrent calls (max %d)", r.limit)
+	}
+	r.calls[id] = cancel
+	return nil
+}
+
+func (r *streamRPC) reply(data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.framed {
+		return writeFrame(r.stream, data)
+	}
+	_, err := r.stream.Write(data)
+	return err
+}
+
+// handleRPC processa mensagens rpc_call e rpc_cancel recebidas na stream
+func (s *Server[T, M]) handleRPC(ctx context.Context, c T, r *streamRPC, msg *Message) {
+	var req RPCRequest
+	if err := s.opts.codec.Unmarshal(msg.Data, &req); err != nil {
+		log.Println("rpc decode error:", err)
+		return
+	}
+	if msg.Type == MessageTypeRPCCancel {
+		r.mu.Lock()
+		cancel, ok := r.calls[req.ID]
+		r.mu.Unlock()
+		if ok {
+			cancel()
+		}
+		return
+	}
+
+	s.handlersMu.RLock()
+	h, ok := s.rpcHandlers[req.Method]
+	s.handlersMu.RUnlock()
+
+	callCtx, cancel := context.WithCancel(ctx)
+	if err := r.start(req.ID, cancel); err != nil {
+		cancel()
+		s.replyRPC(r, RPCResponse{ID: req.ID, Error: err.Error()})
+		return
+	}
+
+	run := func() {
+		defer func() {
+			r.mu.Lock()
+			delete(r.calls, req.ID)
+			r.mu.Unlock()
+			cancel()
+		}()
+		resp := RPCResponse{ID: req.ID}
+		if !ok {
+			resp.Error = fmt.Sprintf("method %q not found", req.Method)
+		} else if result, err := h(callCtx, c, req.Params); err != nil {
+			resp.Error = err.Error()
+		} else if d, err := s.opts.codec.Marshal(result); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Result = d
+		}
+		s.replyRPC(r, resp)
+	}
+
+	if !r.framed {
+		// Sem framing a stream carrega uma única chamada; responde antes de fechar
+		run()
+		return
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		run()
+	}()
+}
+
+// replyRPC envia o resultado de uma chamada na stream dela
+func (s *Server[T, M]) replyRPC(r *streamRPC, resp RPCResponse) {
+	data, err := s.encode(MessageTypeRPCResult, resp)
+	if err != nil {
+		log.Println("rpc encode error:", err)
+		return
+	}
+	if err := r.reply(data); err != nil {
+		log.Println("rpc reply error:", err)
+	}
+}
+
+// encode serializa um payload dentro de um envelope Message usando o Codec do servidor
+func (s *Server[T, M]) encode(msgType string, payload any) ([]byte, error) {
+	d, err := s.opts.codec.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return s.opts.codec.Marshal(&Message{Type: msgType, Data: d})
+}
+
+// RPCClient é o stub client-side que multiplexa chamadas RPC sobre uma única
+// stream QUIC com framing. O servidor deve ter WithFraming(true).
+type RPCClient struct {
+	codec  Codec
+	stream *quic.Stream
+
+	mu      sync.Mutex
+	nextID  atomic.Uint64
+	pending sync.Map // key: uint64, value: chan RPCResponse
+
+	done    chan struct{}
+	readErr error
+}
+
+// NewRPCClient abre a stream de RPC na conexão. codec nil usa JSONCodec.
+func NewRPCClient(conn *quic.Conn, codec Codec) (*RPCClient, error) {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	str, err := conn.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	c := &RPCClient{codec: codec, stream: str, done: make(chan struct{})}
+	go c.readLoop()
+	return c, nil
+}
+
+func (c *RPCClient) readLoop() {
+	defer close(c.done)
+	for {
+		data, err := readFrame(c.stream, DefaultMaxMessageSize)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrClosedPipe
+			}
+			c.readErr = err
+			return
+		}
+		var msg Message
+		if err := c.codec.Unmarshal(data, &msg); err != nil || msg.Type != MessageTypeRPCResult {
+			continue
+		}
+		var resp RPCResponse
+		if err := c.codec.Unmarshal(msg.Data, &resp); err != nil {
+			continue
+		}
+		if ch, ok := c.pending.LoadAndDelete(resp.ID); ok {
+			ch.(chan RPCResponse) <- resp
+		}
+	}
+}
+
+func (c *RPCClient) write(msgType string, payload any) error {
+	d, err := c.codec.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	data, err := c.codec.Marshal(&Message{Type: msgType, Data: d})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return writeFrame(c.stream, data)
+}
+
+// Call invoca method com params e decodifica o resultado em result (pode ser nil).
+// Se ctx for cancelado a chamada é abandonada e o servidor é avisado.
+func (c *RPCClient) Call(ctx context.Context, method string, params any, result any) error {
+	p, err := c.codec.Marshal(params)
+	if err != nil {
+		return err
+	}
+	id := c.nextID.Add(1)
+	ch := make(chan RPCResponse, 1)
+	c.pending.Store(id, ch)
+	if err := c.write(MessageTypeRPCCall, RPCRequest{ID: id, Method: method, Params: p}); err != nil {
+		c.pending.Delete(id)
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != "" {
+			return &RPCError{Message: resp.Error}
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		return c.codec.Unmarshal(resp.Result, result)
+	case <-ctx.Done():
+		c.pending.Delete(id)
+		_ = c.write(MessageTypeRPCCancel, RPCRequest{ID: id})
+		return ctx.Err()
+	case <-c.done:
+		c.pending.Delete(id)
+		return c.readErr
+	}
+}
+
+// Close encerra a stream de RPC
+func (c *RPCClient) Close() error {
+	c.stream.CancelRead(0)
+	return c.stream.Close()
+}
+
+// CallRPC é uma versão tipada de RPCClient.Call
+func CallRPC[R any](ctx context.Context, c *RPCClient, method string, params any) (R, error) {
+	var result R
+	err := c.Call(ctx, method, params, &result)
+	return result, err
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newRPCTest inicia um servidor com framing e abre um RPCClient nele
+func newRPCTest(t *testing.T, setup func(s *Server[*Client, *Message]), opts ...Option) (context.Context, *RPCClient) {
+	t.Helper()
+	s, err := NewTestServer(NewClient, NewMessage, append([]Option{WithFraming(true)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup(s)
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	tc, err := NewTestClient(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tc.Close() })
+	rc, err := NewRPCClient(tc.Conn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rc.Close() })
+	return ctx, rc
+}
+
+func TestRPCCall(t *testing.T) {
+	ctx, rc := newRPCTest(t, func(s *Server[*Client, *Message]) {
+		s.RegisterRPC("add", func(_ context.Context, _ *Client, params json.RawMessage) (any, error) {
+			var p struct{ A, B int }
+			if err := json.Unmarshal(params, &p); err != nil {
+				return nil, err
+			}
+			return p.A + p.B, nil
+		})
+	})
+
+	sum, err := CallRPC[int](ctx, rc, "add", map[string]int{"A": 1, "B": 2})
+	if err != nil || sum != 3 {
+		t.Fatalf("add = %d, %v; want 3", sum, err)
+	}
+
+	var rpcErr *RPCError
+	if err := rc.Call(ctx, "missing", nil, nil); !errors.As(err, &rpcErr) || !strings.Contains(rpcErr.Message, "not found") {
+		t.Fatalf("missing method: err = %v, want a not found RPCError", err)
+	}
+}
+
+func TestRPCCancel(t *testing.T) {
+	entered := make(chan struct{})
+	canceled := make(chan struct{})
+	ctx, rc := newRPCTest(t, func(s *Server[*Client, *Message]) {
+		s.RegisterRPC("wait", func(ctx context.Context, _ *Client, _ json.RawMessage) (any, error) {
+			close(entered)
+			<-ctx.Done()
+			close(canceled)
+			return nil, ctx.Err()
+		})
+	})
+
+	callCtx, cancel := context.WithCancel(ctx)
+	errs := make(chan error, 1)
+	go func() { errs <- rc.Call(callCtx, "wait", nil, nil) }()
+	<-entered
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Call err = %v, want context.Canceled", err)
+	}
+	select {
+	case <-canceled:
+	case <-ctx.Done():
+		t.Fatal("server handler was not canceled")
+	}
+}
+
+func TestRPCConcurrentCalls(t *testing.T) {
+	const n = 8
+	var arrived sync.WaitGroup
+	arrived.Add(n)
+	ctx, rc := newRPCTest(t, func(s *Server[*Client, *Message]) {
+		s.RegisterRPC("echo", func(_ context.Context, _ *Client, params json.RawMessage) (any, error) {
+			// Só responde quando todas as chamadas estão em andamento ao mesmo tempo
+			arrived.Done()
+			arrived.Wait()
+			return params, nil
+		})
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := CallRPC[int](ctx, rc, "echo", i)
+			if err != nil || got != i {
+				t.Errorf("echo %d = %d, %v", i, got, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRPCLimitsCallsPerStream(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	ctx, rc := newRPCTest(t, func(s *Server[*Client, *Message]) {
+		s.RegisterRPC("block", func(_ context.Context, _ *Client, _ json.RawMessage) (any, error) {
+			close(entered)
+			<-release
+			return "done", nil
+		})
+	}, WithMaxRPCCalls(1))
+
+	errs := make(chan error, 1)
+	go func() { errs <- rc.Call(ctx, "block", nil, nil) }()
+	<-entered
+
+	var rpcErr *RPCError
+	if err := rc.Call(ctx, "block", nil, nil); !errors.As(err, &rpcErr) || !strings.Contains(rpcErr.Message, "too many") {
+		t.Fatalf("call over the limit: err = %v, want a too many calls RPCError", err)
+	}
+	close(release)
+	if err := <-errs; err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+}
+
+func TestRPCRejectsDuplicateInFlightID(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	ctx, rc := newRPCTest(t, func(s *Server[*Client, *Message]) {
+		s.RegisterRPC("block", func(_ context.Context, _ *Client, _ json.RawMessage) (any, error) {
+			close(entered)
+			<-release
+			return "done", nil
+		})
+	})
+
+	// Duas chamadas com o mesmo ID, como faria um client que não segue o RPCClient
+	const id = 42
+	ch := make(chan RPCResponse, 2)
+	rc.pending.Store(uint64(id), ch)
+	if err := rc.write(MessageTypeRPCCall, RPCRequest{ID: id, Method: "block"}); err != nil {
+		t.Fatal(err)
+	}
+	<-entered
+	if err := rc.write(MessageTypeRPCCall, RPCRequest{ID: id, Method: "block"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case resp := <-ch:
+		if !strings.Contains(resp.Error, "already in progress") {
+			t.Fatalf("duplicate id response = %+v, want an in progress error", resp)
+		}
+	case <-ctx.Done():
+		t.Fatal("no response to the duplicate id")
+	}
+
+	rc.pending.Store(uint64(id), ch)
+	close(release)
+	select {
+	case resp := <-ch:
+		if resp.Error != "" {
+			t.Fatalf("first call failed: %s", resp.Error)
+		}
+	case <-ctx.Done():
+		t.Fatal("first call did not complete")
+	}
+}
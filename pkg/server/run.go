@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultDrainTimeout é o tempo padrão que Run espera o Stop terminar
+const DefaultDrainTimeout = 10 * time.Second
+
+// WithDrainTimeout define quanto tempo Run e RunUntilSignal esperam o Stop
+// terminar antes de desistir e retornar erro (0 = sem limite)
+func WithDrainTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.drainTimeout = d
+	}
+}
+
+// Run inicia o servidor, bloqueia até ctx terminar e então faz o Stop.
+// Substitui o padrão Start + select{}, em que o defer do Stop nunca roda.
+func (s *Server[T, M]) Run(ctx context.Context) error {
+	s.Start()
+	<-ctx.Done()
+	return s.drain()
+}
+
+// RunUntilSignal roda o servidor até receber um dos sinais (padrão: SIGINT e
+// SIGTERM) e então faz o desligamento gracioso. Um segundo sinal durante o
+// desligamento encerra o processo normalmente.
+func (s *Server[T, M]) RunUntilSignal(signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), signals...)
+	defer stop()
+	s.Start()
+	<-ctx.Done()
+	// Restaura o comportamento padrão dos sinais durante o desligamento
+	stop()
+	return s.drain()
+}
+
+// drain faz o Stop respeitando o tempo limite de WithDrainTimeout
+func (s *Server[T, M]) drain() error {
+	if s.opts.drainTimeout <= 0 {
+		s.Stop()
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Stop()
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(s.opts.drainTimeout):
+		return fmt.Errorf("shutdown timed out after %s", s.opts.drainTimeout)
+	}
+}
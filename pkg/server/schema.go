@@ -0,0 +1,121 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// MessageTypeValidationError é o tipo da resposta enviada quando uma mensagem
+// não passa na validação do schema registrado
+const MessageTypeValidationError = "validation_error"
+
+// ValidationError é o payload de MessageTypeValidationError
+type ValidationError struct {
+	// Type é o tipo da mensagem rejeitada
+	Type    string       `json:"type"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError descreve um campo que falhou na validação
+type FieldError struct {
+	Field string `json:"field"`
+	Tag   string `json:"tag"`
+	Param string `json:"param,omitempty"`
+}
+
+// schemaRegistry guarda os payloads tipados registrados por tipo de mensagem
+type schemaRegistry struct {
+	validate *validator.Validate
+	types    map[string]reflect.Type
+}
+
+// RegisterSchema associa a msgType um payload tipado. proto é uma struct (ou
+// ponteiro para struct) com tags `validate` do go-playground/validator; o Data
+// de cada mensagem desse tipo é decodificado e validado antes do handler, e
+// mensagens inválidas são respondidas com MessageTypeValidationError.
+func (s *Server[T, M]) RegisterSchema(msgType string, proto any) {
+	t := reflect.TypeOf(proto)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	if s.schemas.types == nil {
+		s.schemas.validate = validator.New(validator.WithRequiredStructEnabled())
+		s.schemas.validate.RegisterTagNameFunc(jsonFieldName)
+		s.schemas.types = make(map[string]reflect.Type)
+	}
+	s.schemas.types[msgType] = t
+}
+
+// validateMessage decodifica e valida o payload quando há schema para o tipo.
+// Retorna nil se a mensagem pode seguir para o handler.
+func (s *Server[T, M]) validateMessage(msg *Message) *ValidationError {
+	s.handlersMu.RLock()
+	t, ok := s.schemas.types[msg.Type]
+	v := s.schemas.validate
+	s.handlersMu.RUnlock()
+	if !ok || t == nil {
+		return nil
+	}
+	if msg.IsBinary() && !msg.IsJSON() {
+		return &ValidationError{Type: msg.Type, Message: errNotJSON.Error()}
+	}
+	payload := reflect.New(t).Interface()
+	if err := s.opts.codec.Unmarshal(msg.GetData(), payload); err != nil {
+		return &ValidationError{Type: msg.Type, Message: err.Error()}
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	err := v.Struct(payload)
+	if err == nil {
+		return nil
+	}
+	verr := &ValidationError{Type: msg.Type, Message: err.Error()}
+	var fields validator.ValidationErrors
+	if errors.As(err, &fields) {
+		for _, f := range fields {
+			verr.Fields = append(verr.Fields, FieldError{Field: fieldPath(f.Namespace()), Tag: f.Tag(), Param: f.Param()})
+		}
+	}
+	return verr
+}
+
+// jsonFieldName usa o nome do campo no JSON nos erros de validação
+func jsonFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// fieldPath remove o nome da struct raiz do caminho do campo
+func fieldPath(namespace string) string {
+	_, path, ok := strings.Cut(namespace, ".")
+	if !ok {
+		return namespace
+	}
+	return path
+}
+
+// rejectInvalid envia ao client o erro de validação
+func (s *Server[T, M]) rejectInvalid(conn *Conn, verr *ValidationError) {
+	data, err := s.encode(MessageTypeValidationError, verr)
+	if err != nil {
+		log.Println("validation error encode error:", err)
+		return
+	}
+	if err := conn.sendRaw(data); err != nil {
+		log.Println("validation error reply error:", err)
+	}
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+)
+
+// Send serializa e envia a mensagem para a conexão. As escritas são
+// serializadas por um mutex da conexão; com framing habilitado uma stream de
+// saída persistente é reutilizada e reaberta apenas em caso de erro. Com
+// WithSendQueue a mensagem é apenas enfileirada e pode retornar ErrSendQueueFull.
+// Envios para uma conexão encerrada retornam um erro com ErrConnectionClosed.
+func (c *Conn) Send(msg *Message) error {
+	if c == nil {
+		return ErrConnectionClosed
+	}
+	data, buf, err := c.marshal(msg)
+	if err != nil {
+		return err
+	}
+	defer releaseBuffer(buf)
+	return c.sendRaw(data)
+}
+
+// marshal serializa msg com o codec da conexão. Sem fila de saída o envio termina
+// antes de retornar, então o buffer do pool pode ser devolvido logo depois.
+func (c *Conn) marshal(msg *Message) ([]byte, *bytes.Buffer, error) {
+	codec := c.codec
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	if c.queue != nil {
+		data, err := marshalMessage(codec, msg)
+		return data, nil, err
+	}
+	return marshalPooled(codec, msg)
+}
+
+func (c *Conn) sendRaw(data []byte) error {
+	if err := c.reserveOut(len(data)); err != nil {
+		return err
+	}
+	var err error
+	if c.queue != nil {
+		err = c.queue.push(c, data, (*Conn).writeRaw)
+	} else {
+		err = c.closedErr(c.writeRaw(data))
+	}
+	c.countSent(1, len(data), err)
+	return err
+}
+
+// countSent soma n mensagens e size bytes enviados (ou enfileirados) se o envio não falhou
+func (c *Conn) countSent(n int64, size int, err error) {
+	if err == nil {
+		c.msgsOut.Add(n)
+		c.bytesOut.Add(int64(size))
+	}
+}
+
+func (c *Conn) writeRaw(data []byte) error {
+	return c.writeRawUntil(data, time.Time{})
+}
+
+// writeRawUntil escreve data com um prazo além do WithWriteTimeout (zero = nenhum)
+func (c *Conn) writeRawUntil(data []byte, deadline time.Time) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if !c.framed {
+		return sendStream(c, data, deadline)
+	}
+	return c.writeOut(deadline, func(w io.Writer) error {
+		return writeFrameCompressed(w, data, c.compression, c.compressThreshold)
+	})
+}
+
+// sendFrames envia n mensagens já montadas em frames pela stream de saída persistente
+func (c *Conn) sendFrames(frames []byte, n int64) error {
+	if err := c.reserveOut(len(frames)); err != nil {
+		return err
+	}
+	var err error
+	if c.queue != nil {
+		err = c.queue.push(c, frames, (*Conn).writeFrames)
+	} else {
+		err = c.closedErr(c.writeFrames(frames))
+	}
+	c.countSent(n, len(frames), err)
+	return err
+}
+
+func (c *Conn) writeFrames(frames []byte) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return c.writeOut(time.Time{}, func(w io.Writer) error {
+		_, err := w.Write(frames)
+		return err
+	})
+}
+
+// writeOut escreve na stream de saída, abrindo-a se preciso e descartando-a em
+// caso de erro. Deve ser chamado com sendMu travado.
+func (c *Conn) writeOut(deadline time.Time, write func(w io.Writer) error) error {
+	if c.out == nil {
+		str, err := c.OpenStream()
+		if err != nil {
+			return err
+		}
+		c.out = str
+	}
+	byTimeout := c.setWriteDeadline(c.out, deadline)
+	if err := write(c.out); err != nil {
+		c.out.CancelWrite(0)
+		c.out = nil
+		return c.writeTimeoutErr(err, byTimeout)
+	}
+	if !deadline.IsZero() && c.writeTimeout <= 0 {
+		// O prazo desta escrita não vale para as próximas
+		c.out.SetWriteDeadline(time.Time{})
+	}
+	return nil
+}
+
+// SendReliable envia a mensagem por stream, com entrega garantida e ordenada
+func (c *Conn) SendReliable(msg *Message) error {
+	return c.Send(msg)
+}
+
+// SendUnreliable envia a mensagem por datagrama, sem garantia de entrega.
+// Se a mensagem não couber em um datagrama ela é enviada por stream.
+func (c *Conn) SendUnreliable(msg *Message) error {
+	if c == nil {
+		return ErrConnectionClosed
+	}
+	data, buf, err := c.marshal(msg)
+	if err != nil {
+		return err
+	}
+	defer releaseBuffer(buf)
+	return c.sendUnreliableRaw(data)
+}
+
+func (c *Conn) sendUnreliableRaw(data []byte) error {
+	if err := c.reserveOut(len(data)); err != nil {
+		return err
+	}
+	var err error
+	if c.queue != nil {
+		err = c.queue.push(c, data, (*Conn).writeUnreliable)
+	} else {
+		err = c.closedErr(c.writeUnreliable(data))
+	}
+	c.countSent(1, len(data), err)
+	return err
+}
+
+func (c *Conn) writeUnreliable(data []byte) error {
+	err := c.writeDatagram(data)
+	if errors.Is(err, ErrDatagramTooLarge) {
+		return c.writeRaw(data)
+	}
+	return err
+}
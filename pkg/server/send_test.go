@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// marshalOnlyCodec implementa só o Codec, sem BufferCodec, forçando o envio a
+// alocar um slice por mensagem como antes do pool de buffers
+type marshalOnlyCodec struct{}
+
+func (marshalOnlyCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (marshalOnlyCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// BenchmarkSend mede as alocações do envio de uma mensagem a um client
+// conectado, com e sem os buffers do pool
+func BenchmarkSend(b *testing.B) {
+	msg := &Message{Type: "state", Data: json.RawMessage(`{"x":1.5,"y":-2,"hp":100}`)}
+	for _, bc := range []struct {
+		name  string
+		codec Codec
+	}{
+		{"marshal", marshalOnlyCodec{}},
+		{"pooled", JSONCodec{}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			s, err := NewTestServer(NewClient, NewMessage, WithFraming(true), WithCodec(bc.codec))
+			if err != nil {
+				b.Fatal(err)
+			}
+			s.Start()
+			b.Cleanup(s.Stop)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			tc := connectTestClients(b, ctx, s, 1)[0]
+			go func() {
+				for {
+					if _, err := tc.Receive(ctx); err != nil {
+						return
+					}
+				}
+			}()
+			c := s.GetClients()[0]
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := c.Send(msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// SlowClientPolicy define o que acontece quando a fila de saída de um client enche
+type SlowClientPolicy int
+
+const (
+	// SlowClientDrop descarta a mensagem que não coube na fila
+	SlowClientDrop SlowClientPolicy = iota
+	// SlowClientDisconnect encerra a conexão do client
+	SlowClientDisconnect
+)
+
+// ErrSendQueueFull indica que a fila de saída do client está cheia
+var ErrSendQueueFull = errors.New("send queue full")
+
+// WithSendQueue dá a cada client uma fila de saída com size mensagens, escrita
+// por uma goroutine própria. Send e os broadcasts apenas enfileiram, então um
+// client lento não atrasa os demais (0 = escrita direta).
+func WithSendQueue(size int) Option {
+	return func(o *options) {
+		o.sendQueueSize = size
+	}
+}
+
+// WithSlowClientPolicy define se mensagens que não cabem na fila de saída são
+// descartadas ou se o client é desconectado
+func WithSlowClientPolicy(p SlowClientPolicy) Option {
+	return func(o *options) {
+		o.slowClientPolicy = p
+	}
+}
+
+type queuedSend struct {
+	data  []byte
+	write func(c *Conn, data []byte) error
+}
+
+// sendQueue é a fila de saída de uma conexão
+type sendQueue struct {
+	ch      chan queuedSend
+	policy  SlowClientPolicy
+	dropped *atomic.Int64
+}
+
+func newSendQueue(size int, policy SlowClientPolicy, dropped *atomic.Int64) *sendQueue {
+	return &sendQueue{ch: make(chan queuedSend, size), policy: policy, dropped: dropped}
+}
+
+// push enfileira a escrita sem bloquear, aplicando a política quando a fila está cheia
+func (q *sendQueue) push(c *Conn, data []byte, write func(*Conn, []byte) error) error {
+	if c.Context().Err() != nil {
+		return ErrConnectionClosed
+	}
+	select {
+	case q.ch <- queuedSend{data: data, write: write}:
+		return nil
+	default:
+	}
+	q.dropped.Add(1)
+	if q.policy == SlowClientDisconnect {
+		c.closeWithReason(ReasonKicked, CloseCodeSlowClient, ErrSendQueueFull.Error())
+	}
+	return ErrSendQueueFull
+}
+
+// run escreve as mensagens enfileiradas até o contexto terminar, passando a
+// onErr as falhas de escrita enquanto a conexão está aberta
+func (q *sendQueue) run(ctx context.Context, c *Conn, onErr func(error)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-q.ch:
+			if err := job.write(c, job.data); err != nil && c.Context().Err() == nil {
+				onErr(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import "sync"
+
+// WithSequenceValidation descarta mensagens com Seq repetido ou mais antigo que
+// as últimas windowSize sequências recebidas do client, chamando o OnReplay.
+// Mensagens sem Seq (0) não são validadas.
+func WithSequenceValidation(windowSize int) Option {
+	return func(o *options) {
+		o.seqWindow = windowSize
+	}
+}
+
+// seqWindow registra as sequências recebidas dentro de uma janela deslizante
+type seqWindow struct {
+	mu      sync.Mutex
+	size    uint64
+	highest uint64
+	seen    []uint64 // bitset circular indexado por seq % size
+}
+
+func newSeqWindow(size int) *seqWindow {
+	return &seqWindow{size: uint64(size), seen: make([]uint64, (size+63)/64)}
+}
+
+func (w *seqWindow) bit(seq uint64) (int, uint64) {
+	i := seq % w.size
+	return int(i / 64), 1 << (i % 64)
+}
+
+// accept registra seq e retorna false se ela for duplicada ou estiver fora da janela
+func (w *seqWindow) accept(seq uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if seq > w.highest {
+		if seq-w.highest >= w.size {
+			clear(w.seen)
+		} else {
+			for s := w.highest + 1; s < seq; s++ {
+				word, mask := w.bit(s)
+				w.seen[word] &^= mask
+			}
+		}
+		w.highest = seq
+		word, mask := w.bit(seq)
+		w.seen[word] |= mask
+		return true
+	}
+	if w.highest-seq >= w.size {
+		return false
+	}
+	word, mask := w.bit(seq)
+	if w.seen[word]&mask != 0 {
+		return false
+	}
+	w.seen[word] |= mask
+	return true
+}
@@ -3,14 +3,21 @@ package server
 import (
 	"context"
 	"crypto/tls"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"math/rand"
 	"net"
+	"net/http"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/quic-go/quic-go"
+	"github.com/quic-go/webtransport-go"
 )
 
 type MessageConstraint[T any] interface {
@@ -20,6 +27,10 @@ type MessageConstraint[T any] interface {
 
 type MessageFactory[T any] func(msg *Message) T
 
+// StrictMessageFactory é o MessageFactory que pode recusar a mensagem: o erro
+// vai para o OnMalformedMessage e o OnMsg não é chamado
+type StrictMessageFactory[T any] func(msg *Message) (T, error)
+
 type ClientConstraint[T any] interface {
 	*T
 	ClientInterface
@@ -27,9 +38,71 @@ type ClientConstraint[T any] interface {
 
 type Conn struct {
 	*quic.Conn
+	// session é definido para conexões WebTransport, que não possuem *quic.Conn próprio
+	session *webtransport.Session
+
+	limiter *tokenBucket
+	codec   Codec
+	framed  bool
+	// compression e compressThreshold valem para os frames de saída
+	compression       Compression
+	compressThreshold int
+
+	sendMu sync.Mutex
+	out    *Stream
+
+	closeReason atomic.Pointer[DisconnectReason]
+	malformed   atomic.Int64
+	connectedAt time.Time
+
+	tagsOnce sync.Once
+	tags     *tagIndex
+	reliable *reliableLink
+	metrics  *connMetrics
+	// ready é fechado quando o OnConn assíncrono termina
+	ready chan struct{}
+	// detached é definido nas conexões sem transporte criadas pelo Replay
+	detached        *detachedConn
+	superseded      atomic.Bool
+	breaker         *circuitBreaker
+	seq             uint64
+	queue           *sendQueue
+	protocolVersion int
+	seqs            *seqWindow
+	reorder         *reorderBuffer
+	// msgsIn e msgsOut contam as mensagens recebidas e enviadas, para o ListClients
+	msgsIn  atomic.Int64
+	msgsOut atomic.Int64
+	// bytesIn e bytesOut contam o tráfego da conexão; quota aplica WithPerClientBandwidthQuota
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+	quota    *bandwidthQuota
+	// writeTimeout e slowPolicy aplicam WithWriteTimeout às escritas em stream
+	writeTimeout time.Duration
+	slowPolicy   SlowClientPolicy
+	// streamSlots é o semáforo de WithMaxStreamsPerConn
+	streamSlots chan struct{}
+	// room é a última sala vista, para o EventRoomChange
+	room        atomic.Pointer[string]
+	firstMsg    *time.Timer
+	gotFirstMsg atomic.Bool
+	active      sync.WaitGroup
+	// drained é fechado quando o atendimento da conexão termina, com os
+	// handlers já encerrados
+	drained chan struct{}
 }
 
 func (c *Conn) OpenStream() (*Stream, error) {
+	if c.detached != nil {
+		return nil, errDetachedConn
+	}
+	if c.session != nil {
+		stream, err := c.session.OpenStream()
+		if err != nil {
+			return nil, err
+		}
+		return &Stream{wt: stream}, nil
+	}
 	stream, err := c.Conn.OpenStream()
 	if err != nil {
 		return nil, err
@@ -37,11 +110,80 @@ func (c *Conn) OpenStream() (*Stream, error) {
 	return &Stream{Stream: stream}, nil
 }
 
+// SendDatagram envia os dados em um datagrama. Retorna ErrDatagramTooLarge se
+// os dados excederem MaxDatagramSize e ErrConnectionClosed se a conexão já terminou.
 func (c *Conn) SendDatagram(data []byte) error {
+	if c == nil {
+		return ErrConnectionClosed
+	}
+	if err := c.reserveOut(len(data)); err != nil {
+		return err
+	}
+	err := c.writeDatagram(data)
+	c.countSent(0, len(data), err)
+	return err
+}
+
+// writeDatagram envia o datagrama sem passar pela cota de saída
+func (c *Conn) writeDatagram(data []byte) error {
+	if c.Context().Err() != nil {
+		// O quic-go descarta em silêncio datagramas de conexões fechadas
+		return ErrConnectionClosed
+	}
+	err := c.sendDatagram(data)
+	var tooLarge *quic.DatagramTooLargeError
+	if errors.As(err, &tooLarge) {
+		return fmt.Errorf("%w: %d bytes (max %d)", ErrDatagramTooLarge, len(data), tooLarge.MaxDatagramPayloadSize)
+	}
+	return c.closedErr(err)
+}
+
+func (c *Conn) sendDatagram(data []byte) error {
+	if c.detached != nil {
+		return errDetachedConn
+	}
+	if c.session != nil {
+		return c.session.SendDatagram(data)
+	}
 	return c.Conn.SendDatagram(data)
 }
 
+// datagramProbe é maior que qualquer datagrama QUIC possível; o quic-go rejeita
+// pelo tamanho antes de ler o conteúdo, então nada é enviado
+var datagramProbe = make([]byte, 1<<16)
+
+// MaxDatagramSize retorna o maior payload aceito por SendDatagram no momento
+// (0 se datagramas não estiverem disponíveis). O valor pode crescer conforme o
+// MTU do caminho é descoberto.
+func (c *Conn) MaxDatagramSize() int {
+	var tooLarge *quic.DatagramTooLargeError
+	if errors.As(c.sendDatagram(datagramProbe), &tooLarge) {
+		return int(tooLarge.MaxDatagramPayloadSize)
+	}
+	return 0
+}
+
+func (c *Conn) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	if c.detached != nil {
+		return nil, errDetachedConn
+	}
+	if c.session != nil {
+		return c.session.ReceiveDatagram(ctx)
+	}
+	return c.Conn.ReceiveDatagram(ctx)
+}
+
 func (c *Conn) AcceptStream(ctx context.Context) (*Stream, error) {
+	if c.detached != nil {
+		return nil, errDetachedConn
+	}
+	if c.session != nil {
+		stream, err := c.session.AcceptStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &Stream{wt: stream}, nil
+	}
 	stream, err := c.Conn.AcceptStream(ctx)
 	if err != nil {
 		return nil, err
@@ -49,185 +191,967 @@ func (c *Conn) AcceptStream(ctx context.Context) (*Stream, error) {
 	return &Stream{Stream: stream}, nil
 }
 
+func (c *Conn) CloseWithError(code quic.ApplicationErrorCode, desc string) error {
+	if c.detached != nil {
+		return nil
+	}
+	if c.session != nil {
+		return c.session.CloseWithError(webtransport.SessionErrorCode(code), desc)
+	}
+	return c.Conn.CloseWithError(code, desc)
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	if c.detached != nil {
+		return c.detached.addr
+	}
+	if c.session != nil {
+		return c.session.RemoteAddr()
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	if c.detached != nil {
+		return c.detached.addr
+	}
+	if c.session != nil {
+		return c.session.LocalAddr()
+	}
+	return c.Conn.LocalAddr()
+}
+
+func (c *Conn) ConnectionState() quic.ConnectionState {
+	if c.detached != nil {
+		return quic.ConnectionState{}
+	}
+	if c.session != nil {
+		return c.session.ConnectionState()
+	}
+	return c.Conn.ConnectionState()
+}
+
+func (c *Conn) Context() context.Context {
+	if c.detached != nil {
+		return c.detached.ctx
+	}
+	if c.session != nil {
+		return c.session.Context()
+	}
+	return c.Conn.Context()
+}
+
+// Stream é uma stream bidirecional QUIC ou, para sessões WebTransport, uma stream WebTransport
 type Stream struct {
 	*quic.Stream
+	wt *webtransport.Stream
+}
+
+func (s *Stream) Read(p []byte) (int, error) {
+	if s.wt != nil {
+		return s.wt.Read(p)
+	}
+	return s.Stream.Read(p)
 }
+
+func (s *Stream) Write(p []byte) (int, error) {
+	if s.wt != nil {
+		return s.wt.Write(p)
+	}
+	return s.Stream.Write(p)
+}
+
+func (s *Stream) Close() error {
+	if s.wt != nil {
+		return s.wt.Close()
+	}
+	return s.Stream.Close()
+}
+
+func (s *Stream) CancelRead(code quic.StreamErrorCode) {
+	if s.wt != nil {
+		s.wt.CancelRead(webtransport.StreamErrorCode(code))
+		return
+	}
+	s.Stream.CancelRead(code)
+}
+
+func (s *Stream) CancelWrite(code quic.StreamErrorCode) {
+	if s.wt != nil {
+		s.wt.CancelWrite(webtransport.StreamErrorCode(code))
+		return
+	}
+	s.Stream.CancelWrite(code)
+}
+
+func (s *Stream) SetReadDeadline(t time.Time) error {
+	if s.wt != nil {
+		return s.wt.SetReadDeadline(t)
+	}
+	return s.Stream.SetReadDeadline(t)
+}
+
+func (s *Stream) SetWriteDeadline(t time.Time) error {
+	if s.wt != nil {
+		return s.wt.SetWriteDeadline(t)
+	}
+	return s.Stream.SetWriteDeadline(t)
+}
+
+func (s *Stream) SetDeadline(t time.Time) error {
+	if s.wt != nil {
+		return s.wt.SetDeadline(t)
+	}
+	return s.Stream.SetDeadline(t)
+}
+
 type ClientFactory[T any] func(conn *Conn) T
 
+// Códigos de aplicação usados ao fechar conexões pelo servidor
+const (
+	CloseCodeServerFull  quic.ApplicationErrorCode = 0x100
+	CloseCodeRateLimited quic.ApplicationErrorCode = 0x101
+	CloseCodeAuthFailed  quic.ApplicationErrorCode = 0x102
+	CloseCodeMalformed   quic.ApplicationErrorCode = 0x103
+	CloseCodeBanned      quic.ApplicationErrorCode = 0x104
+	// CloseCodeSessionResumed fecha a conexão antiga quando a sessão é retomada em outra
+	CloseCodeSessionResumed quic.ApplicationErrorCode = 0x105
+	// CloseCodeSlowClient fecha a conexão cuja fila de saída encheu
+	CloseCodeSlowClient quic.ApplicationErrorCode = 0x106
+	// CloseCodeUnsupportedVersion recusa clients com versão de protocolo não registrada
+	CloseCodeUnsupportedVersion quic.ApplicationErrorCode = 0x107
+	// CloseCodeBandwidthExceeded fecha a conexão que excedeu a cota de WithPerClientBandwidthQuota
+	CloseCodeBandwidthExceeded quic.ApplicationErrorCode = 0x108
+	// CloseCodeWriteTimeout fecha a conexão cuja escrita excedeu o prazo de WithWriteTimeout
+	CloseCodeWriteTimeout quic.ApplicationErrorCode = 0x109
+	// CloseCodeTooManyStreams fecha a conexão que excedeu WithMaxStreamsPerConn
+	CloseCodeTooManyStreams quic.ApplicationErrorCode = 0x10a
+	// CloseCodeHandshakeTimeout fecha a conexão que não enviou a primeira mensagem no prazo de WithHandshakeTimeout
+	CloseCodeHandshakeTimeout quic.ApplicationErrorCode = 0x10b
+	// CloseCodeServerShutdown fecha as conexões no Stop
+	CloseCodeServerShutdown quic.ApplicationErrorCode = 0x10c
+	// CloseCodeRejected recusa a conexão pelo OnAccept quando o erro não é um RejectError
+	CloseCodeRejected quic.ApplicationErrorCode = 0x10d
+)
+
+// Códigos usados ao cancelar streams pelo servidor
+const (
+	StreamCodeMessageTooLarge quic.StreamErrorCode = 0x100
+	// StreamCodeReadTimeout cancela a stream que não completou a mensagem no prazo de WithReadTimeout
+	StreamCodeReadTimeout quic.StreamErrorCode = 0x101
+	// StreamCodeFileCanceled cancela a stream de um arquivo do SendFile ou do OnFile
+	StreamCodeFileCanceled quic.StreamErrorCode = 0x102
+)
+
 type OnConnectFn[T any] func(c T)
-type OnDisconnectFn[T any] func(c T, err error)
-type OnMessageFn[T, M any] func(c T, msg M)
-type TickFn[T, M any] func(s *Server[T, M])
+type OnDisconnectFn[T any] func(c T, info DisconnectInfo)
+type OnMessageFn[T, M any] func(ctx context.Context, c T, msg M)
+
+// TickFn é chamado a cada tick com o tempo real decorrido desde o tick anterior.
+// Após pausas longas (ex.: GC) dt pode ser grande; handlers de física devem limitá-lo.
+type TickFn[T, M any] func(s *Server[T, M], dt time.Duration)
 
 type Server[T, M any] struct {
-	ln    quic.Listener
-	conns sync.Map // key: *Conn, value: T
+	listeners   []*listener
+	listenersMu sync.Mutex
+	started     bool
+	tlsConf     *tls.Config
+	conns       clientStore[T]
+	connCount   atomic.Int64
+	opts        options
+
+	ticksBehind atomic.Int64
+	// tickOverruns conta os ticks que passaram do intervalo; overrunLog e
+	// overrunsSinceLog limitam o aviso no log, só lidos pelo loop de tick
+	tickOverruns     atomic.Int64
+	overrunLog       time.Time
+	overrunsSinceLog int
+
+	pool    *broadcastPool
+	tags    *tagIndex
+	wt      *webtransport.Server
+	spatial spatialIndex
+
+	handlersMu  sync.RWMutex
+	handlers    map[string]OnMessageFn[T, M]
+	rpcHandlers map[string]RPCHandler[T]
+	schemas     schemaRegistry
+	versions    map[int]MessageFactory[M]
+	timeSync    atomic.Bool
 
 	ClientFactory  ClientFactory[T]
 	MessageFactory MessageFactory[M]
-	OnConn         OnConnectFn[T]
-	OnDisc         OnDisconnectFn[T]
-	OnMsg          OnMessageFn[T, M]
-	TickFn         TickFn[T, M]
+	// StrictMessageFactory, se definido, substitui o MessageFactory (mas não os
+	// das versões de RegisterProtocolVersion) e pode recusar mensagens
+	StrictMessageFactory StrictMessageFactory[M]
+	OnConn               OnConnectFn[T]
+	OnReconnect          OnConnectFn[T]
+	OnDisc               OnDisconnectFn[T]
+	OnMsg                OnMessageFn[T, M]
+	OnUnhandled          OnMessageFn[T, M]
+	TickFn               TickFn[T, M]
+	// OnTickOverrun é chamado quando um tick leva mais que o intervalo (budget)
+	OnTickOverrun func(took, budget time.Duration)
+	// OnAccept decide, antes do OnConn, se o client é aceito. Um erro recusa a
+	// conexão: com *RejectError o client recebe o código e o motivo, senão
+	// CloseCodeRejected e o texto do erro. Não é chamado ao retomar uma sessão.
+	OnAccept      func(c T) error
+	OnServerFull  func(remoteAddr net.Addr)
+	OnRateLimited func(c T)
+	OnDatagram    func(c T, data []byte)
+	// OnStream recebe as streams aceitas no lugar da leitura padrão de mensagens.
+	// A stream é fechada quando ele retorna.
+	OnStream func(c T, stream *Stream)
+	// OnMalformedMessage é chamado quando uma mensagem recebida não pode ser decodificada
+	OnMalformedMessage func(c T, raw []byte, err error)
+	OnPresenceChange   func(ev PresenceEvent)
+	// OnHandlerTimeout é chamado quando um handler excede o prazo de WithHandlerTimeout
+	OnHandlerTimeout func(c T, msg M)
+	// OnReplay é chamado quando WithSequenceValidation descarta uma mensagem
+	OnReplay func(c T, seq uint64)
+	// OnSequenceGap é chamado quando o buffer de reordenação desiste das sequências from..to
+	OnSequenceGap func(c T, from, to uint64)
+	// OnBandwidthExceeded é chamado quando os n bytes recebidos excedem a cota do client
+	OnBandwidthExceeded func(c T, n int)
+	// OnFile recebe os arquivos enviados pelo client; r é o conteúdo, lido direto
+	// da stream. Se ele retornar sem ler tudo a transferência é cancelada.
+	OnFile func(c T, name string, r io.Reader)
+	// OnFileProgress é chamado a cada trecho escrito pelo SendFile; total é -1
+	// quando o tamanho do reader não é conhecido
+	OnFileProgress func(c T, name string, sent, total int64)
+	// OnError recebe as falhas de transporte como *TransportError; Fatal indica
+	// que um listener parou de aceitar conexões
+	OnError func(err error)
 
-	tps    time.Duration
-	ctx    context.Context
-	wg     sync.WaitGroup
-	cancel context.CancelFunc
+	tps         atomic.Int64 // intervalo do tick em nanossegundos
+	tickReset   chan struct{}
+	ctx         context.Context
+	wg          sync.WaitGroup
+	cancel      context.CancelFunc
+	recorder    atomic.Pointer[recorder]
+	rand        *rand.Rand
+	randSrc     *lockedSource
+	restored    restoredStates
+	held        heldClients[T]
+	msgPool     *messagePool
+	connSeq     atomic.Uint64
+	dropped     atomic.Int64
+	tickPaused  atomic.Bool
+	adaptive    adaptiveTick
+	roomTicks   roomTicks[T]
+	ready       chan struct{}
+	readyOnce   sync.Once
+	batch       tickBatch
+	adminMu     sync.Mutex
+	admins      []*http.Server
+	topics      *topicIndex
+	entities    EntityStore
+	replicator  entityReplicator
+	broadcastMu sync.Mutex
+	events      eventBus
+	typeCounts  typeCounters
+	live        liveConns
+	sessions    sessionNonces
 }
 
-func New[T, M any](addr string, tickRate int, clientFactory ClientFactory[T], messageFactory MessageFactory[M]) (*Server[T, M], error) {
-	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+func New[T, M any](addr string, clientFactory ClientFactory[T], messageFactory MessageFactory[M], opts ...Option) (*Server[T, M], error) {
+	o, err := buildOptions(opts)
 	if err != nil {
 		return nil, err
 	}
-	udpConn, err := net.ListenUDP("udp", udpAddr)
+	s := newServer(nil, o, clientFactory, messageFactory)
+	if addr != "" {
+		if err := s.Listen(addr); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// NewWithTransport cria um servidor sobre um quic.Transport fornecido pelo usuário,
+// permitindo compartilhar a porta UDP com outros protocolos (ex.: HTTP/3) ou usar
+// um net.PacketConn customizado. O transport não é fechado pelo Stop.
+func NewWithTransport[T, M any](tr *quic.Transport, clientFactory ClientFactory[T], messageFactory MessageFactory[M], opts ...Option) (*Server[T, M], error) {
+	o, err := buildOptions(opts)
 	if err != nil {
 		return nil, err
 	}
-	tr := &quic.Transport{Conn: udpConn}
-	tlsConf := GenerateTLSConfig()
-	ln, err := tr.Listen(tlsConf, &quic.Config{
-		EnableDatagrams:                true,
-		MaxIdleTimeout:                 5 * time.Minute,
-		MaxIncomingStreams:             1000, // Aumentar limite de streams
-		MaxIncomingUniStreams:          1000,
-		InitialStreamReceiveWindow:     512 * 1024,  // 512KB
-		MaxStreamReceiveWindow:         1024 * 1024, // 1MB
-		InitialConnectionReceiveWindow: 1024 * 1024, // 1MB
-		MaxConnectionReceiveWindow:     1024 * 1024, // 1MB
-	})
+	installConnContext(tr, o)
+	tlsConf := o.serverTLSConfig()
+	ln, err := tr.Listen(tlsConf, o.quicConfig)
 	if err != nil {
 		return nil, err
 	}
+	s := newServer([]*listener{{tr: tr, ln: ln, external: true}}, o, clientFactory, messageFactory)
+	s.tlsConf = tlsConf
+	return s, nil
+}
 
-	t := time.Second / time.Duration(tickRate)
-
-	return &Server[T, M]{
-		ln:             *ln,
-		tps:            t,
+func newServer[T, M any](lns []*listener, o options, clientFactory ClientFactory[T], messageFactory MessageFactory[M]) *Server[T, M] {
+	s := &Server[T, M]{
+		listeners:      lns,
+		opts:           o,
+		tags:           newTagIndex(),
+		topics:         newTopicIndex(),
 		ClientFactory:  clientFactory,
 		MessageFactory: messageFactory,
-	}, nil
+	}
+	s.tps.Store(int64(time.Second / time.Duration(o.tickRate)))
+	s.tickReset = make(chan struct{}, 1)
+	s.ready = make(chan struct{})
+	s.initRand()
+	if o.webTransportPath != "" {
+		s.wt = s.newWebTransportServer()
+	}
+	return s
 }
 
 func (s *Server[T, M]) Start() {
 	ctx, cancel := context.WithCancel(context.Background())
 	s.cancel = cancel
 	s.ctx = ctx
-	s.wg.Add(1)
-	go s.acceptLoop()
+	if s.opts.broadcastWorkers > 0 {
+		s.pool = newBroadcastPool(s.opts.broadcastWorkers)
+	}
+	if s.opts.messageWorkers > 0 {
+		s.msgPool = newMessagePool(s.opts.messageWorkers, s.opts.orderedMessages)
+	}
+	s.listenersMu.Lock()
+	s.started = true
+	for _, l := range s.listeners {
+		s.wg.Add(1)
+		go s.acceptLoop(l.ln)
+	}
+	s.listenersMu.Unlock()
 	s.wg.Add(1)
 	go s.tickLoop()
-	log.Printf("Server started, listening on %s\n", s.ln.Addr().String())
+	s.wg.Add(1)
+	go s.banSweeper()
+	log.Printf("Server started, listening on %s\n", joinAddrs(s.Addrs()))
+	s.readyOnce.Do(func() { close(s.ready) })
 }
 
-func (s *Server[T, M]) Stop() {
-	s.cancel()
-	s.ln.Close()
-	s.wg.Wait()
+// Listen passa a escutar também em addr, com os mesmos clients, salas e
+// callbacks. Pode ser chamado várias vezes (ex.: IPv4 e IPv6) antes ou depois
+// do Start; New com endereço vazio cria o servidor sem escutar em nenhum.
+func (s *Server[T, M]) Listen(addr string) error {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	if s.tlsConf == nil {
+		s.tlsConf = s.opts.serverTLSConfig()
+	}
+	lns, err := listen(addr, s.tlsConf, s.opts)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", addr, err)
+	}
+	s.listeners = append(s.listeners, lns...)
+	if s.started {
+		for _, l := range lns {
+			s.wg.Add(1)
+			go s.acceptLoop(l.ln)
+		}
+	}
+	return nil
 }
 
-func (s *Server[T, M]) tickLoop() {
-	defer s.wg.Done()
-	ticker := time.NewTicker(s.tps)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-s.ctx.Done():
-			return
-		case <-ticker.C:
-			if s.TickFn != nil {
-				s.TickFn(s)
-			}
+// Addr retorna o endereço do primeiro listener, útil ao usar a porta ":0"
+// (nil se o servidor não escuta em nenhum endereço)
+func (s *Server[T, M]) Addr() net.Addr {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	if len(s.listeners) == 0 {
+		return nil
+	}
+	return s.listeners[0].ln.Addr()
+}
+
+// Addrs retorna os endereços de todos os listeners, sem repetir os sockets de WithReusePort
+func (s *Server[T, M]) Addrs() []net.Addr {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	var addrs []net.Addr
+	seen := make(map[string]bool)
+	for _, l := range s.listeners {
+		a := l.ln.Addr()
+		if !seen[a.String()] {
+			seen[a.String()] = true
+			addrs = append(addrs, a)
 		}
 	}
+	return addrs
+}
+
+func joinAddrs(addrs []net.Addr) string {
+	parts := make([]string, len(addrs))
+	for i, a := range addrs {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Ready retorna um canal fechado quando o Start termina e os loops de accept estão rodando
+func (s *Server[T, M]) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Stop encerra o servidor: para de aceitar conexões, libera as leituras
+// bloqueadas e espera as streams em andamento terminarem, até o
+// WithShutdownTimeout. Cada conexão é fechada com CloseCodeServerShutdown.
+func (s *Server[T, M]) Stop() {
+	if s.cancel == nil {
+		// Stop antes do Start: não há o que esperar, só os sockets a fechar
+		s.listenersMu.Lock()
+		closeListeners(s.listeners)
+		s.listenersMu.Unlock()
+		return
+	}
+	s.cancel()
+	s.listenersMu.Lock()
+	for _, l := range s.listeners {
+		l.ln.Close()
+	}
+	s.listenersMu.Unlock()
+	s.stopAdmin()
+	s.awaitShutdown()
+	if s.wt != nil {
+		s.wt.Close()
+	}
+	// Os transports fecham por último para as conexões terminarem antes
+	s.listenersMu.Lock()
+	closeListeners(s.listeners)
+	s.listenersMu.Unlock()
+	if s.msgPool != nil {
+		s.msgPool.close()
+	}
+	s.releaseHeld()
+	if s.pool != nil {
+		s.pool.close()
+	}
+	s.events.close()
 }
 
-func (s *Server[T, M]) acceptLoop() {
+func (s *Server[T, M]) acceptLoop(ln *quic.Listener) {
 	defer s.wg.Done()
 	for {
-		conn, err := s.ln.Accept(s.ctx)
+		conn, err := ln.Accept(s.ctx)
 		if err != nil {
 			select {
 			case <-s.ctx.Done():
 				return
 			default:
-				log.Println("accept error:", err)
-				continue
 			}
+			if listenerClosed(err) {
+				s.reportError("accept", ln.Addr(), true, err)
+				return
+			}
+			s.reportError("accept", ln.Addr(), false, err)
+			continue
+		}
+		if s.wt != nil && isWebTransportConn(conn) {
+			go s.wt.ServeQUICConn(conn)
+			continue
+		}
+		if !s.reserveSlot() {
+			conn.CloseWithError(CloseCodeServerFull, ErrServerFull.Error())
+			if s.OnServerFull != nil {
+				s.OnServerFull(conn.RemoteAddr())
+			}
+			continue
 		}
 		s.wg.Add(1)
-		go s.handleConnection(&Conn{conn})
+		go s.handleConnection(s.newConn(conn))
+	}
+}
+
+func (s *Server[T, M]) newConn(conn *quic.Conn) *Conn {
+	c := &Conn{
+		Conn:              conn,
+		codec:             s.opts.codec,
+		framed:            s.opts.framing,
+		compressThreshold: s.opts.compressThreshold,
+		connectedAt:       time.Now(),
+		tags:              s.tags,
+		seq:               s.connSeq.Add(1),
+		writeTimeout:      s.opts.writeTimeout,
+		slowPolicy:        s.opts.slowClientPolicy,
+		drained:           make(chan struct{}),
+	}
+	if s.opts.msgRate > 0 {
+		c.limiter = newTokenBucket(s.opts.msgRate, s.opts.msgBurst)
+	}
+	if s.opts.maxStreamsPerConn > 0 {
+		c.streamSlots = make(chan struct{}, s.opts.maxStreamsPerConn)
+	}
+	if s.opts.bandwidthQuota > 0 {
+		c.quota = newBandwidthQuota(s.opts.bandwidthQuota, s.opts.rateLimitPolicy)
+	}
+	if conn != nil {
+		c.metrics = metricsFromContext(conn.Context())
+		// Sessões WebTransport e conexões do Replay não negociam compressão
+		c.compression = negotiatedCompression(s.opts.compression, conn.ConnectionState().TLS.NegotiatedProtocol)
+	}
+	if s.opts.handlerTimeout > 0 && s.opts.breakerThreshold > 0 {
+		c.breaker = &circuitBreaker{}
+	}
+	if s.opts.reliableWindow > 0 {
+		c.reliable = newReliableLink(c.SendDatagram, s.opts.reliableWindow)
+	}
+	if s.opts.seqWindow > 0 {
+		c.seqs = newSeqWindow(s.opts.seqWindow)
+	}
+	if s.opts.reorderSize > 0 {
+		c.reorder = newReorderBuffer(c, s.opts.reorderSize, s.opts.reorderTimeout)
+	}
+	if s.opts.sendQueueSize > 0 {
+		c.queue = newSendQueue(s.opts.sendQueueSize, s.opts.slowClientPolicy, &s.dropped)
+	}
+	return c
+}
+
+// reserveSlot reserva uma vaga de conexão respeitando o limite configurado
+func (s *Server[T, M]) reserveSlot() bool {
+	limit := int64(s.opts.maxConns)
+	for {
+		n := s.connCount.Load()
+		if limit > 0 && n >= limit {
+			return false
+		}
+		if s.connCount.CompareAndSwap(n, n+1) {
+			return true
+		}
 	}
 }
 
 func (s *Server[T, M]) handleConnection(conn *Conn) {
 	defer s.wg.Done()
+	defer close(conn.drained)
+	s.live.add(conn)
+	defer s.live.remove(conn)
+	s.awaitFirstMessage(conn)
+	if len(s.supportedVersions()) > 0 {
+		if err := s.negotiateVersion(conn); err != nil {
+			log.Println("version error:", err)
+			conn.closeWithReason(ReasonKicked, CloseCodeUnsupportedVersion, err.Error())
+			s.connCount.Add(-1)
+			return
+		}
+	}
 	c := s.ClientFactory(conn)
-	s.conns.Store(conn, c)
-
-	if s.OnConn != nil {
-		s.OnConn(c)
+	assignID(c, s.opts.idGenerator)
+	applyCertMeta(conn, c)
+	if s.opts.authenticator != nil {
+		if err := s.authenticate(conn, c); err != nil {
+			log.Println("auth error:", err)
+			conn.closeWithReason(ReasonKicked, CloseCodeAuthFailed, ErrAuthFailed.Error())
+			s.connCount.Add(-1)
+			return
+		}
+	}
+	resumed := false
+	if s.opts.reconnectWindow > 0 {
+		var err error
+		if c, resumed, err = s.startSession(conn, c); err != nil {
+			log.Println("session error:", err)
+			conn.closeWithReason(ReasonKicked, CloseCodeAuthFailed, "session handshake failed")
+			s.connCount.Add(-1)
+			return
+		}
+	}
+	if !resumed && !s.accept(conn, c) {
+		s.connCount.Add(-1)
+		return
+	}
+	if resumed {
+		s.resumeClient(conn, c)
+	} else {
+		if err := s.applyRestored(conn, c); err != nil {
+			log.Println("restore state error:", err)
+		}
+		if s.opts.asyncOnConn {
+			conn.ready = make(chan struct{})
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				defer close(conn.ready)
+				s.addClient(conn, c)
+			}()
+		} else {
+			s.addClient(conn, c)
+		}
 	}
 
 	ctx, cancel := context.WithCancel(s.ctx)
 	defer cancel()
 
+	if conn.reliable != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			conn.reliable.run(ctx)
+		}()
+	}
+	if conn.queue != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			conn.queue.run(ctx, conn, func(err error) {
+				s.reportError("send queue write", conn.RemoteAddr(), false, err)
+			})
+		}()
+	}
+	if s.OnDatagram != nil || conn.reliable != nil {
+		s.wg.Add(1)
+		go s.datagramLoop(ctx, conn, c)
+	}
+	if s.OnFile != nil {
+		s.wg.Add(1)
+		go s.fileLoop(ctx, conn, c)
+	}
+
 	for {
 		stream, err := conn.AcceptStream(ctx)
 		if err != nil {
-			log.Println("stream accept error:", err)
-			if s.OnDisc != nil {
-				s.OnDisc(c, err)
+			if conn.ready != nil {
+				// O OnDisc só vem depois do OnConn
+				<-conn.ready
+			}
+			shutdown := s.ctx.Err() != nil
+			// As streams e os handlers em andamento (inclusive os enfileirados no
+			// WithMessageWorkers) terminam antes do retrato e do OnDisc
+			conn.active.Wait()
+			// Uma conexão substituída pela retomada não gera OnDisc, e o client já
+			// pertence à nova conexão: não pode ser lido aqui
+			if !conn.superseded.Load() {
+				info := DisconnectInfo{
+					Reason: classifyDisconnect(conn, err, s.ctx.Err() != nil),
+					Err:    disconnectErr(err),
+				}.withSummary(conn, c)
+				if info.Reason == ReasonError {
+					s.reportError("stream accept", conn.RemoteAddr(), false, err)
+				}
+				if !s.holdForReconnect(conn, c, info) {
+					s.removeClient(conn, c, info)
+				}
 			}
-			s.conns.Delete(conn)
+			if shutdown {
+				conn.CloseWithError(CloseCodeServerShutdown, "server shutdown")
+			}
+			s.connCount.Add(-1)
 			return
 		}
+		if !s.acquireStream(ctx, conn) {
+			// O próximo AcceptStream retorna o erro da conexão encerrada
+			stream.CancelRead(0)
+			stream.CancelWrite(0)
+			continue
+		}
 		s.wg.Add(1)
-		go s.handleStream(stream, c)
+		conn.active.Add(1)
+		go s.handleStream(ctx, conn, stream, c)
+	}
+}
+
+// addClient registra o client e notifica a conexão
+func (s *Server[T, M]) addClient(conn *Conn, c T) {
+	s.conns.Store(conn, c)
+	s.record(RecordConnect, conn, c, nil, 0)
+	if s.OnConn != nil {
+		s.OnConn(c)
+	}
+	s.notifyPresence(PresenceJoined, conn, c)
+	s.wakeRoomTick(c)
+	s.trackRoom(conn, c)
+	s.emitClient(EventConnect, conn, c, DisconnectInfo{})
+}
+
+// removeClient notifica a desconexão e remove o client dos registros do servidor
+func (s *Server[T, M]) removeClient(conn *Conn, c T, info DisconnectInfo) {
+	s.finishDisconnect(conn, c, info)
+	s.conns.Delete(conn)
+	s.tags.removeConn(conn)
+	s.topics.removeConn(conn)
+}
+
+// finishDisconnect grava e notifica a saída do client
+func (s *Server[T, M]) finishDisconnect(conn *Conn, c T, info DisconnectInfo) {
+	info = info.withSummary(conn, c)
+	s.record(RecordDisconnect, conn, c, nil, info.Reason)
+	if s.OnDisc != nil {
+		s.OnDisc(c, info)
+	}
+	s.notifyPresence(PresenceLeft, conn, c)
+	if info.Reason == ReasonKicked {
+		s.emitClient(EventKick, conn, c, info)
 	}
+	s.emitClient(EventDisconnect, conn, c, info)
 }
 
-func (s *Server[T, M]) handleStream(stream *Stream, c T) {
+func (s *Server[T, M]) handleMalformed(conn *Conn, c T, data []byte, err error) {
+	if s.OnMalformedMessage != nil {
+		s.OnMalformedMessage(c, data, err)
+	} else {
+		log.Println("unmarshal message error:", err)
+	}
+	n := conn.malformed.Add(1)
+	if s.opts.maxMalformed > 0 && n >= int64(s.opts.maxMalformed) {
+		conn.closeWithReason(ReasonKicked, CloseCodeMalformed, "too many malformed messages")
+	}
+}
+
+// datagramLoop entrega os datagramas recebidos da conexão ao OnDatagram
+func (s *Server[T, M]) datagramLoop(ctx context.Context, conn *Conn, c T) {
+	defer s.wg.Done()
+	if !awaitReady(ctx, conn) {
+		return
+	}
+	for {
+		data, err := conn.ReceiveDatagram(ctx)
+		if err != nil {
+			return
+		}
+		if conn.reliable != nil {
+			var ok bool
+			if data, ok = conn.reliable.handle(data); !ok {
+				continue
+			}
+		}
+		if !s.acceptBytes(conn, c, len(data)) || !s.allowMessage(conn, c) {
+			continue
+		}
+		conn.markFirstMessage()
+		s.record(RecordDatagram, conn, c, data, 0)
+		if s.OnDatagram != nil {
+			s.OnDatagram(c, data)
+		}
+	}
+}
+
+func (s *Server[T, M]) handleStream(ctx context.Context, conn *Conn, stream *Stream, c T) {
 	defer s.wg.Done()
+	defer conn.active.Done()
+	defer conn.releaseStream()
 	defer stream.Close()
-	data, err := io.ReadAll(stream)
-	if err != nil {
-		log.Println("read stream error:", err)
+	defer unblockReads(ctx, stream)()
+	if s.OnStream != nil {
+		conn.markFirstMessage()
+		if awaitReady(ctx, conn) {
+			s.OnStream(c, stream)
+		}
 		return
 	}
-	var baseMsg Message
-	if err := json.Unmarshal(data, &baseMsg); err != nil {
-		log.Println("unmarshal message error:", err)
+	rpc := newStreamRPC(stream, s.opts.framing, s.opts.maxRPCCalls)
+	defer rpc.wg.Wait()
+	if !s.opts.framing {
+		if s.opts.readTimeout > 0 {
+			stream.SetReadDeadline(time.Now().Add(s.opts.readTimeout))
+		}
+		// io.ReadAll trata o fechamento da escrita pelo client (EOF) como leitura completa
+		data, err := s.readMessage(stream)
+		if err != nil {
+			s.handleReadError(conn, c, stream, err)
+			return
+		}
+		if len(data) == 0 || !awaitReady(ctx, conn) || !s.acceptBytes(conn, c, len(data)) {
+			return
+		}
+		s.handleData(ctx, conn, c, rpc, data)
 		return
 	}
-	msg := s.MessageFactory(&baseMsg)
-	if s.OnMsg != nil {
-		s.OnMsg(c, msg)
+	var r io.Reader = stream
+	var deadlines *frameDeadlineReader
+	if s.opts.readTimeout > 0 {
+		deadlines = newFrameDeadlineReader(stream, s.opts.readTimeout)
+		r = deadlines
 	}
+	for {
+		data, err := readFrame(r, s.opts.maxMessageSize)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			s.handleReadError(conn, c, stream, err)
+			return
+		}
+		if deadlines != nil {
+			deadlines.frameDone()
+		}
+		if !awaitReady(ctx, conn) {
+			return
+		}
+		if !s.acceptBytes(conn, c, len(data)) {
+			continue
+		}
+		s.handleData(ctx, conn, c, rpc, data)
+	}
+}
+
+// handleReadError trata falhas de leitura da stream. Erros causados pelo fim
+// normal da conexão (client desconectou, servidor parando) não são logados.
+func (s *Server[T, M]) handleReadError(conn *Conn, c T, stream *Stream, err error) {
+	if errors.Is(err, ErrMessageTooLarge) {
+		stream.CancelRead(StreamCodeMessageTooLarge)
+		// A mensagem recusada também conta no limite, para que uma enxurrada
+		// de mensagens grandes demais não escape dele
+		s.allowMessage(conn, c)
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) && conn.Context().Err() == nil && s.ctx.Err() == nil {
+		// Stream ociosa ou lenta demais, não uma falha do transporte
+		stream.CancelRead(StreamCodeReadTimeout)
+		s.reportError("read stream timeout", conn.RemoteAddr(), false, fmt.Errorf("%w: %w", ErrReadTimeout, err))
+		return
+	}
+	if classifyDisconnect(conn, err, s.ctx.Err() != nil) != ReasonError {
+		return
+	}
+	s.reportError("read stream", conn.RemoteAddr(), false, err)
 }
 
-func (s *Server[T, M]) Broadcast(msg *Message) {
-	data, err := json.Marshal(msg)
+// handleData decodifica uma mensagem recebida e a entrega aos handlers
+func (s *Server[T, M]) handleData(ctx context.Context, conn *Conn, c T, rpc *streamRPC, data []byte) {
+	conn.msgsIn.Add(1)
+	if !s.allowMessage(conn, c) {
+		return
+	}
+	s.record(RecordMessage, conn, c, data, 0)
+	var baseMsg Message
+	if err := s.opts.codec.Unmarshal(data, &baseMsg); err != nil {
+		s.handleMalformed(conn, c, data, err)
+		return
+	}
+	baseMsg.codec = s.opts.codec
+	conn.markFirstMessage()
+	s.typeCounts.add(baseMsg.Type)
+	if baseMsg.Seq != 0 && conn.seqs != nil && !conn.seqs.accept(baseMsg.Seq) {
+		if s.OnReplay != nil {
+			s.OnReplay(c, baseMsg.Seq)
+		}
+		return
+	}
+	if baseMsg.Type == MessageTypeRPCCall || baseMsg.Type == MessageTypeRPCCancel {
+		s.handleRPC(ctx, c, rpc, &baseMsg)
+		return
+	}
+	if baseMsg.Type == MessageTypeTimeSync && s.timeSync.Load() {
+		s.handleTimeSync(conn, &baseMsg)
+		return
+	}
+	if verr := s.validateMessage(&baseMsg); verr != nil {
+		s.rejectInvalid(conn, verr)
+		return
+	}
+	msg, err := s.newMessage(conn, &baseMsg)
 	if err != nil {
-		log.Println("marshal message error:", err)
+		s.handleMalformed(conn, c, data, fmt.Errorf("message factory: %w", err))
 		return
 	}
-	s.conns.Range(func(key, value interface{}) bool {
-		conn := key.(*Conn)
-		// Usar datagramas em vez de streams para broadcasts
-		err := conn.SendDatagram(data)
-		if err != nil {
-			log.Println("send datagram error:", err)
+	if baseMsg.Seq != 0 && conn.reorder != nil {
+		conn.reorder.push(baseMsg.Seq, func() { s.deliverMessage(ctx, conn, c, &baseMsg, msg) }, func(from, to uint64) {
+			if s.OnSequenceGap != nil {
+				s.OnSequenceGap(c, from, to)
+			}
+		})
+		return
+	}
+	s.deliverMessage(ctx, conn, c, &baseMsg, msg)
+}
+
+// deliverMessage entrega a mensagem decodificada ao pool de workers ou direto ao handler
+func (s *Server[T, M]) deliverMessage(ctx context.Context, conn *Conn, c T, base *Message, msg M) {
+	ctx, end := s.traceMessage(ctx, conn, c, base)
+	if s.msgPool != nil {
+		conn.active.Add(1)
+		if !s.msgPool.submit(conn, func() {
+			defer conn.active.Done()
+			defer end()
+			s.runHandler(ctx, conn, c, base.Type, msg)
+		}) {
+			conn.active.Done()
+			end()
 		}
+		return
+	}
+	defer end()
+	s.runHandler(ctx, conn, c, base.Type, msg)
+}
+
+// readMessage lê a stream inteira respeitando o tamanho máximo configurado
+func (s *Server[T, M]) readMessage(r io.Reader) ([]byte, error) {
+	limit := s.opts.maxMessageSize
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > limit {
+		return nil, ErrMessageTooLarge
+	}
+	return data, nil
+}
+
+// Broadcast envia a mensagem para todos os clients. Falhas individuais não
+// interrompem o envio; os erros são agregados com errors.Join.
+func (s *Server[T, M]) Broadcast(msg *Message) error {
+	return s.BroadcastWhere(msg, nil)
+}
+
+// BroadcastWhere envia a mensagem apenas para os clients em que pred retorna true.
+// A mensagem é serializada uma única vez; pred nil envia para todos.
+func (s *Server[T, M]) BroadcastWhere(msg *Message, pred func(c T) bool) error {
+	data, buf, err := s.marshalBroadcast(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	defer releaseBuffer(buf)
+	var targets []broadcastTarget
+	s.conns.Range(func(conn *Conn, client T) bool {
+		if pred != nil && !pred(client) {
+			return true
+		}
+		targets = append(targets, broadcastTarget{conn: conn, id: clientID(conn, client)})
 		return true
 	})
+	// Usar datagramas em vez de streams para broadcasts; mensagens maiores que o
+	// datagrama máximo são enviadas por stream
+	return s.deliver(targets, data, (*Conn).sendUnreliableRaw)
 }
 
-// BroadcastStream usa streams para mensagens que precisam de entrega garantida
+// BroadcastReliable envia a mensagem para todos os clients por stream, com entrega garantida
+func (s *Server[T, M]) BroadcastReliable(msg *Message) error {
+	data, buf, err := s.marshalBroadcast(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	defer releaseBuffer(buf)
+	var targets []broadcastTarget
+	s.conns.Range(func(conn *Conn, client T) bool {
+		targets = append(targets, broadcastTarget{conn: conn, id: clientID(conn, client)})
+		return true
+	})
+	return s.deliver(targets, data, (*Conn).sendRaw)
+}
+
+// clientID retorna o ID do client ou, se vazio, o endereço remoto da conexão
+func clientID(conn *Conn, value interface{}) string {
+	if c, ok := value.(ClientInterface); ok && c.GetID() != "" {
+		return c.GetID()
+	}
+	return conn.RemoteAddr().String()
+}
+
+// BroadcastStream usa streams para mensagens que precisam de entrega garantida.
+// Com WithFraming cada client recebe a mensagem na sua stream de saída
+// persistente, em vez de uma stream nova por envio. Os envios são concorrentes,
+// exceto com WithOrderedBroadcasts, em que seguem a ordem dos demais broadcasts.
 func (s *Server[T, M]) BroadcastStream(msg *Message) {
-	data, err := json.Marshal(msg)
+	if s.opts.orderedBroadcasts {
+		if err := s.BroadcastReliable(msg); err != nil {
+			log.Println("send stream error:", err)
+		}
+		return
+	}
+	data, err := marshalMessage(s.opts.codec, msg)
 	if err != nil {
 		log.Println("marshal message error:", err)
 		return
@@ -236,25 +1160,15 @@ func (s *Server[T, M]) BroadcastStream(msg *Message) {
 	// Usar um semáforo para limitar streams concorrentes
 	semaphore := make(chan struct{}, 10) // Máximo 10 streams concorrentes
 
-	s.conns.Range(func(key, value interface{}) bool {
-		conn := key.(*Conn)
-
+	s.conns.Range(func(conn *Conn, _ T) bool {
 		// Adquirir permissão
 		semaphore <- struct{}{}
 
 		go func(c *Conn) {
 			defer func() { <-semaphore }() // Liberar permissão
 
-			str, err := c.OpenStream()
-			if err != nil {
-				log.Println("open stream error:", err)
-				return
-			}
-			defer str.Close()
-
-			_, err = str.Write(data)
-			if err != nil {
-				log.Println("write stream error:", err)
+			if err := c.sendRaw(data); err != nil {
+				log.Println("send stream error:", err)
 			}
 		}(conn)
 
@@ -262,20 +1176,34 @@ func (s *Server[T, M]) BroadcastStream(msg *Message) {
 	})
 }
 
+// sendStream abre uma stream, escreve data e fecha a stream
+func sendStream(conn *Conn, data []byte, deadline time.Time) error {
+	str, err := conn.OpenStream()
+	if err != nil {
+		return fmt.Errorf("open stream: %w", err)
+	}
+	defer str.Close()
+	byTimeout := conn.setWriteDeadline(str, deadline)
+	if _, err := str.Write(data); err != nil {
+		str.CancelWrite(0)
+		return fmt.Errorf("write stream: %w", conn.writeTimeoutErr(err, byTimeout))
+	}
+	return nil
+}
+
 func (s *Server[T, M]) SendDatagram(conn *Conn, data []byte) error {
 	return conn.SendDatagram(data)
 }
 
-// BroadcastDatagram envia dados via datagramas para todos os clientes (mais eficiente)
-func (s *Server[T, M]) BroadcastDatagram(data []byte) {
-	s.conns.Range(func(key, value interface{}) bool {
-		conn := key.(*Conn)
-		err := conn.SendDatagram(data)
-		if err != nil {
-			log.Println("send datagram error:", err)
-		}
+// BroadcastDatagram envia dados via datagramas para todos os clientes (mais eficiente).
+// Os envios usam o pool de broadcast quando configurado e os erros são agregados.
+func (s *Server[T, M]) BroadcastDatagram(data []byte) error {
+	var targets []broadcastTarget
+	s.conns.Range(func(conn *Conn, client T) bool {
+		targets = append(targets, broadcastTarget{conn: conn, id: clientID(conn, client)})
 		return true
 	})
+	return s.deliver(targets, data, (*Conn).SendDatagram)
 }
 
 func GenerateTLSConfig() *tls.Config {
@@ -293,8 +1221,8 @@ func GenerateTLSConfig() *tls.Config {
 }
 
 // NewDefaultServer cria um servidor usando o client padrão e message padrão
-func NewDefaultServer(addr string, tickRate int) (*Server[*Client, *Message], error) {
-	return New(addr, tickRate, NewClient, NewMessage)
+func NewDefaultServer(addr string, opts ...Option) (*Server[*Client, *Message], error) {
+	return New(addr, NewClient, NewMessage, opts...)
 }
 
 // NewMessage cria uma nova instância de Message
@@ -305,21 +1233,28 @@ func NewMessage(msg *Message) *Message {
 // Funções auxiliares para trabalhar com clients
 func (s *Server[T, M]) GetClients() []T {
 	var clients []T
-	s.conns.Range(func(key, value interface{}) bool {
-		if client, ok := value.(T); ok {
-			clients = append(clients, client)
-		}
+	s.conns.Range(func(_ *Conn, client T) bool {
+		clients = append(clients, client)
 		return true
 	})
 	return clients
 }
 
+// RangeClients chama fn para cada client conectado sem alocar uma slice, como
+// GetClients faz. A iteração para quando fn retorna false.
+func (s *Server[T, M]) RangeClients(fn func(c T) bool) {
+	s.conns.Range(func(_ *Conn, client T) bool {
+		return fn(client)
+	})
+}
+
 func (s *Server[T, M]) GetClientByConn(conn *Conn) (T, bool) {
-	var zero T
-	if value, ok := s.conns.Load(conn); ok {
-		if client, ok := value.(T); ok {
-			return client, true
-		}
-	}
-	return zero, false
+	return s.conns.Load(conn)
+}
+
+// IsConnected indica se o client está registrado no servidor com a conexão
+// ainda aberta. Um client mantido por WithReconnectWindow não conta até retomar a sessão.
+func (s *Server[T, M]) IsConnected(c T) bool {
+	conn, err := s.connOf(c)
+	return err == nil && conn.Context().Err() == nil
 }
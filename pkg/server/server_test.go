@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// closeCode espera a conexão do client ser fechada pelo servidor e retorna o código
+func closeCode(t *testing.T, ctx context.Context, tc *TestClient) quic.ApplicationErrorCode {
+	t.Helper()
+	select {
+	case <-tc.Conn.Context().Done():
+	case <-ctx.Done():
+		t.Fatal("connection was not closed")
+	}
+	var appErr *quic.ApplicationError
+	if err := context.Cause(tc.Conn.Context()); !errors.As(err, &appErr) || !appErr.Remote {
+		t.Fatalf("close error = %v, want a remote application close", err)
+	}
+	return appErr.ErrorCode
+}
+
+func TestMaxConnectionsRejectsAndFreesSlot(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithMaxConnections(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := make(chan net.Addr, 1)
+	s.OnServerFull = func(addr net.Addr) { full <- addr }
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	first := connectTestClients(t, ctx, s, 1)[0]
+
+	extra, err := NewTestClient(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer extra.Close()
+	if code := closeCode(t, ctx, extra); code != CloseCodeServerFull {
+		t.Fatalf("close code = %#x, want CloseCodeServerFull", code)
+	}
+	select {
+	case addr := <-full:
+		if addr.String() != extra.Conn.LocalAddr().String() {
+			t.Fatalf("OnServerFull got %v, want %v", addr, extra.Conn.LocalAddr())
+		}
+	case <-ctx.Done():
+		t.Fatal("OnServerFull was not called")
+	}
+
+	// A vaga volta depois que o primeiro client desconecta
+	first.Close()
+	waitFor(t, ctx, func() bool { return s.connCount.Load() == 0 })
+	connectTestClients(t, ctx, s, 1)
+	select {
+	case addr := <-full:
+		t.Fatalf("OnServerFull called again for %v", addr)
+	default:
+	}
+}
+
+func TestStopClosesClientsCleanly(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 8)
+	s.OnError = func(err error) { errs <- err }
+	msgs := make(chan struct{}, 1)
+	s.OnMsg = func(context.Context, *Client, *Message) { msgs <- struct{}{} }
+	s.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clients := connectTestClients(t, ctx, s, 2)
+
+	// Uma stream escrita e fechada pelo client é uma leitura completa, não um erro
+	if err := clients[0].Send(&Message{Type: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-msgs:
+	case <-ctx.Done():
+		t.Fatal("message was not delivered")
+	}
+
+	s.Stop()
+	for i, tc := range clients {
+		if code := closeCode(t, ctx, tc); code != CloseCodeServerShutdown {
+			t.Fatalf("client %d close code = %#x, want CloseCodeServerShutdown", i, code)
+		}
+		var idleErr *quic.IdleTimeoutError
+		if errors.As(context.Cause(tc.Conn.Context()), &idleErr) {
+			t.Fatalf("client %d saw an idle timeout instead of the application close", i)
+		}
+	}
+	select {
+	case err := <-errs:
+		t.Fatalf("clean close reported a transport error: %v", err)
+	default:
+	}
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSessionTokenTTL é a validade padrão dos tokens de sessão
+const DefaultSessionTokenTTL = 24 * time.Hour
+
+var (
+	errInvalidSessionToken = errors.New("invalid session token")
+	errExpiredSessionToken = errors.New("session token expired")
+	errUsedSessionToken    = errors.New("session token already used")
+	errSessionIdentity     = errors.New("session token belongs to another identity")
+)
+
+// WithSessionTokenTTL define por quanto tempo um token de sessão vale desde a
+// emissão (padrão: DefaultSessionTokenTTL)
+func WithSessionTokenTTL(d time.Duration) Option {
+	return func(o *options) {
+		o.sessionTokenTTL = d
+	}
+}
+
+// sessionClaims são os dados assinados no token de sessão
+type sessionClaims struct {
+	id      string
+	subject string
+	issued  time.Time
+	nonce   [16]byte
+}
+
+// sessionNonces guarda o nonce do último token emitido para cada ID. Só esse
+// token é aceito, e uma vez: a resposta do handshake traz o próximo.
+type sessionNonces struct {
+	mu        sync.Mutex
+	current   map[string]nonceEntry
+	lastPrune time.Time
+}
+
+type nonceEntry struct {
+	nonce  [16]byte
+	issued time.Time
+	used   bool
+}
+
+func randomSecret() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// authSubject retorna a claim "sub" do Authenticator guardada no client, ou ""
+func authSubject(c any) string {
+	ci, ok := c.(ClientInterface)
+	if !ok {
+		return ""
+	}
+	claims, _ := ci.GetMeta()[MetaKeyAuthClaims].(map[string]any)
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// issueSessionToken assina o ID, a identidade autenticada, o horário e um nonce
+// novo, invalidando o token anterior do ID
+func (s *Server[T, M]) issueSessionToken(id, subject string) string {
+	c := sessionClaims{id: id, subject: subject, issued: time.Now()}
+	rand.Read(c.nonce[:])
+
+	n := &s.sessions
+	n.mu.Lock()
+	if n.current == nil {
+		n.current = make(map[string]nonceEntry)
+	}
+	if c.issued.Sub(n.lastPrune) >= time.Minute {
+		for k, e := range n.current {
+			if c.issued.Sub(e.issued) > s.opts.sessionTokenTTL {
+				delete(n.current, k)
+			}
+		}
+		n.lastPrune = c.issued
+	}
+	n.current[id] = nonceEntry{nonce: c.nonce, issued: c.issued}
+	n.mu.Unlock()
+
+	// issued(8) | nonce(16) | len(subject)(2) | subject | id
+	payload := make([]byte, 0, 26+len(subject)+len(id))
+	payload = binary.BigEndian.AppendUint64(payload, uint64(c.issued.Unix()))
+	payload = append(payload, c.nonce[:]...)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(len(subject)))
+	payload = append(payload, subject...)
+	payload = append(payload, id...)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(s.signSession(payload))
+}
+
+func (s *Server[T, M]) signSession(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.opts.reconnectSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// parseSessionToken valida assinatura e validade do token, sem consumi-lo
+func (s *Server[T, M]) parseSessionToken(token string) (sessionClaims, error) {
+	var c sessionClaims
+	payloadPart, macPart, ok := strings.Cut(token, ".")
+	if !ok {
+		return c, errInvalidSessionToken
+	}
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(payloadPart)
+	if err != nil || len(payload) < 26 {
+		return c, errInvalidSessionToken
+	}
+	mac, err := enc.DecodeString(macPart)
+	if err != nil || !hmac.Equal(mac, s.signSession(payload)) {
+		return c, errInvalidSessionToken
+	}
+	c.issued = time.Unix(int64(binary.BigEndian.Uint64(payload)), 0)
+	copy(c.nonce[:], payload[8:24])
+	subLen := int(binary.BigEndian.Uint16(payload[24:]))
+	if len(payload) < 26+subLen {
+		return c, errInvalidSessionToken
+	}
+	c.subject = string(payload[26 : 26+subLen])
+	c.id = string(payload[26+subLen:])
+	if time.Since(c.issued) > s.opts.sessionTokenTTL {
+		return c, errExpiredSessionToken
+	}
+	return c, nil
+}
+
+// consumeSessionToken aceita o token uma única vez, e só se for o último
+// emitido para o ID. IDs sem registro (tokens de antes de um reinício) são
+// aceitos uma vez dentro do TTL.
+func (s *Server[T, M]) consumeSessionToken(c sessionClaims) error {
+	n := &s.sessions
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.current == nil {
+		n.current = make(map[string]nonceEntry)
+	}
+	if e, ok := n.current[c.id]; ok && (e.used || e.nonce != c.nonce) {
+		return errUsedSessionToken
+	}
+	n.current[c.id] = nonceEntry{issued: c.issued, used: true}
+	return nil
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clientHandshake faz um handshake do lado do client: uma stream com a
+// mensagem e a resposta do servidor na mesma stream
+func clientHandshake(ctx context.Context, tc *TestClient, msgType string, payload, reply any) error {
+	d, err := tc.codec.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	data, err := tc.codec.Marshal(&Message{Type: msgType, Data: d})
+	if err != nil {
+		return err
+	}
+	str, err := tc.Conn.OpenStreamSync(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := str.Write(data); err != nil {
+		return err
+	}
+	str.Close()
+	resp, err := io.ReadAll(str)
+	if err != nil {
+		return err
+	}
+	var msg Message
+	if err := tc.codec.Unmarshal(resp, &msg); err != nil {
+		return err
+	}
+	if reply == nil {
+		return nil
+	}
+	return tc.codec.Unmarshal(msg.Data, reply)
+}
+
+func newSessionServer(t *testing.T, opts ...Option) *Server[*Client, *Message] {
+	t.Helper()
+	opts = append([]Option{
+		WithReconnectWindow(time.Minute),
+		WithAuthenticator(func(token string) (map[string]any, error) {
+			return map[string]any{"sub": token}, nil
+		}),
+	}, opts...)
+	s, err := NewTestServer(NewClient, NewMessage, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	t.Cleanup(func() { s.Stop() })
+	return s
+}
+
+// connectSession autentica como subject e faz o handshake de sessão com token
+func connectSession(ctx context.Context, t *testing.T, s *Server[*Client, *Message], subject, token string) (*TestClient, SessionResponse, error) {
+	t.Helper()
+	tc, err := NewTestClient(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tc.Close() })
+	var resp SessionResponse
+	if err := clientHandshake(ctx, tc, MessageTypeAuth, AuthRequest{Token: subject}, nil); err != nil {
+		return tc, resp, err
+	}
+	err = clientHandshake(ctx, tc, MessageTypeSession, SessionRequest{Token: token}, &resp)
+	if err == nil && resp.Token == "" {
+		err = errors.New("empty session response")
+	}
+	return tc, resp, err
+}
+
+// rejectSession espera que o handshake com token seja recusado com CloseCodeAuthFailed
+func rejectSession(ctx context.Context, t *testing.T, s *Server[*Client, *Message], subject, token string) {
+	t.Helper()
+	tc, _, err := connectSession(ctx, t, s, subject, token)
+	if err == nil {
+		t.Fatal("session handshake accepted the token")
+	}
+	if code := closeCode(t, ctx, tc); code != CloseCodeAuthFailed {
+		t.Fatalf("close code = %d, want CloseCodeAuthFailed", code)
+	}
+}
+
+func TestSessionTokenSingleUse(t *testing.T) {
+	s := newSessionServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, first, err := connectSession(ctx, t, s, "alice", "")
+	if err != nil {
+		t.Fatalf("first session: %v", err)
+	}
+	_, next, err := connectSession(ctx, t, s, "alice", first.Token)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if !next.Resumed || next.Token == first.Token {
+		t.Fatalf("resume = %+v, want a resumed session with a rotated token", next)
+	}
+
+	rejectSession(ctx, t, s, "alice", first.Token)
+	rejectSession(ctx, t, s, "alice", strings.Replace(next.Token, ".", "A.", 1))
+
+	_, last, err := connectSession(ctx, t, s, "alice", next.Token)
+	if err != nil {
+		t.Fatalf("rotated token: %v", err)
+	}
+	if !last.Resumed {
+		t.Fatal("rotated token did not resume the session")
+	}
+}
+
+func TestSessionTokenExpiry(t *testing.T) {
+	// Os tokens guardam o horário em segundos: com TTL de 1ns já nascem vencidos
+	s := newSessionServer(t, WithSessionTokenTTL(time.Nanosecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, first, err := connectSession(ctx, t, s, "alice", "")
+	if err != nil {
+		t.Fatalf("first session: %v", err)
+	}
+	rejectSession(ctx, t, s, "alice", first.Token)
+}
+
+func TestSessionResumeRequiresSameIdentity(t *testing.T) {
+	s := newSessionServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, first, err := connectSession(ctx, t, s, "alice", "")
+	if err != nil {
+		t.Fatalf("first session: %v", err)
+	}
+
+	if _, _, err := connectSession(ctx, t, s, "bob", first.Token); err == nil {
+		t.Fatal("bob resumed alice's session")
+	}
+
+	_, resumed, err := connectSession(ctx, t, s, "alice", first.Token)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if !resumed.Resumed {
+		t.Fatal("session was not resumed")
+	}
+	if resumed.Token == first.Token {
+		t.Fatal("token was not rotated on resume")
+	}
+
+	if _, _, err := connectSession(ctx, t, s, "alice", first.Token); err == nil {
+		t.Fatal("used token resumed the session again")
+	}
+}
+
+func TestSessionTakeoverWaitsForHandlers(t *testing.T) {
+	s := newSessionServer(t)
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	seen := make(chan *Conn, 1)
+	s.Handle("slow", func(_ context.Context, c *Client, _ *Message) {
+		close(entered)
+		<-release
+		seen <- c.Conn
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	old, first, err := connectSession(ctx, t, s, "alice", "")
+	if err != nil {
+		t.Fatalf("first session: %v", err)
+	}
+	if err := old.Send(&Message{Type: "slow"}); err != nil {
+		t.Fatal(err)
+	}
+	<-entered
+
+	resumed := make(chan error, 1)
+	go func() {
+		_, _, err := connectSession(ctx, t, s, "alice", first.Token)
+		resumed <- err
+	}()
+	select {
+	case err := <-resumed:
+		t.Fatalf("session resumed while the old handler was running: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	oldConn := <-seen
+	if err := <-resumed; err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	waitFor(t, ctx, func() bool { return len(s.GetClients()) == 1 })
+	if s.GetClients()[0].Conn == oldConn {
+		t.Fatal("client still bound to the old connection")
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultShutdownTimeout é o tempo padrão que o Stop espera as conexões terminarem
+const DefaultShutdownTimeout = 5 * time.Second
+
+// WithShutdownTimeout define quanto tempo o Stop espera as streams em andamento
+// terminarem antes de fechar à força as conexões restantes (0 = sem limite).
+// Leituras bloqueadas são liberadas logo no início do Stop; o prazo cobre
+// handlers e escritas ainda em curso.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.shutdownTimeout = d
+	}
+}
+
+// liveConns guarda as conexões em atendimento, inclusive as que ainda estão no
+// handshake, para o Stop poder fechá-las à força
+type liveConns struct {
+	mu    sync.Mutex
+	conns map[*Conn]struct{}
+}
+
+func (l *liveConns) add(conn *Conn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conns == nil {
+		l.conns = make(map[*Conn]struct{})
+	}
+	l.conns[conn] = struct{}{}
+}
+
+func (l *liveConns) remove(conn *Conn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.conns, conn)
+}
+
+func (l *liveConns) closeAll(desc string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for conn := range l.conns {
+		conn.CloseWithError(CloseCodeServerShutdown, desc)
+	}
+}
+
+// unblockReads libera as leituras da stream quando ctx termina, para o Stop
+// não esperar o idle timeout de clients parados. A função retornada desarma.
+func unblockReads(ctx context.Context, str interface{ SetReadDeadline(time.Time) error }) func() bool {
+	return context.AfterFunc(ctx, func() { str.SetReadDeadline(time.Now()) })
+}
+
+// awaitShutdown espera as goroutines do servidor e, passado o
+// WithShutdownTimeout, fecha à força as conexões que ainda não terminaram
+func (s *Server[T, M]) awaitShutdown() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.wg.Wait()
+	}()
+	if s.opts.shutdownTimeout <= 0 {
+		<-done
+		return
+	}
+	timer := time.NewTimer(s.opts.shutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return
+	case <-timer.C:
+	}
+	s.live.closeAll("shutdown timeout")
+	<-done
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStopUnblocksIdleReads(t *testing.T) {
+	for _, framed := range []bool{false, true} {
+		t.Run(map[bool]string{false: "unframed", true: "framed"}[framed], func(t *testing.T) {
+			const timeout = 2 * time.Second
+			s, err := NewTestServer(NewClient, NewMessage, WithFraming(framed), WithShutdownTimeout(timeout), WithMaxStreamsPerConn(4))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Start()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			clients := connectTestClients(t, ctx, s, 3)
+
+			// Cada client deixa uma stream no meio de uma mensagem, com o servidor bloqueado na leitura
+			for _, tc := range clients {
+				str, err := tc.Conn.OpenStream()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := str.Write([]byte{0, 0}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Com WithMaxStreamsPerConn as streams em atendimento ocupam streamSlots
+			waitFor(t, ctx, func() bool {
+				busy := 0
+				s.conns.Range(func(conn *Conn, _ *Client) bool {
+					if len(conn.streamSlots) > 0 {
+						busy++
+					}
+					return true
+				})
+				return busy == len(clients)
+			})
+
+			start := time.Now()
+			s.Stop()
+			if took := time.Since(start); took >= timeout {
+				t.Fatalf("Stop took %v, want less than the %v shutdown timeout", took, timeout)
+			}
+		})
+	}
+}
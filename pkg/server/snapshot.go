@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Snapshotter é implementado por clients com estado próprio que deve
+// sobreviver a um reinício. ID, sala, tags e meta já são salvos pelo servidor;
+// SnapshotState só precisa cobrir os demais campos do T.
+type Snapshotter interface {
+	SnapshotState() ([]byte, error)
+	RestoreState(data []byte) error
+}
+
+// RoomSetter é implementado por clients cuja sala pode ser restaurada
+type RoomSetter interface {
+	SetRoom(room string)
+}
+
+// ClientSnapshot é o estado salvo de um client. Valores de meta passam por
+// JSON e voltam como os tipos genéricos do encoding/json.
+type ClientSnapshot struct {
+	ID    string                 `json:"id"`
+	Room  string                 `json:"room,omitempty"`
+	Tags  []string               `json:"tags,omitempty"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+	State json.RawMessage        `json:"state,omitempty"`
+}
+
+// serverSnapshot é o formato serializado por SnapshotState
+type serverSnapshot struct {
+	Version int              `json:"version"`
+	Clients []ClientSnapshot `json:"clients"`
+}
+
+const snapshotVersion = 1
+
+// restoredStates guarda os estados restaurados até o client correspondente voltar
+type restoredStates struct {
+	mu      sync.Mutex
+	clients map[string]ClientSnapshot
+}
+
+// SnapshotState serializa o estado dos clients conectados: ID, sala, tags,
+// meta e o estado próprio de clients que implementam Snapshotter
+func (s *Server[T, M]) SnapshotState() ([]byte, error) {
+	snap := serverSnapshot{Version: snapshotVersion}
+	var err error
+	s.conns.Range(func(conn *Conn, c T) bool {
+		var cs ClientSnapshot
+		cs, err = snapshotClient(conn, c)
+		if err != nil {
+			return false
+		}
+		snap.Clients = append(snap.Clients, cs)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(snap)
+}
+
+func snapshotClient(conn *Conn, client any) (ClientSnapshot, error) {
+	cs := ClientSnapshot{ID: clientID(conn, client), Tags: conn.tagIndex().tagsOf(conn)}
+	if r, ok := client.(RoomMember); ok {
+		cs.Room = r.GetRoom()
+	}
+	if c, ok := client.(ClientInterface); ok {
+		cs.Meta = c.GetMeta()
+	}
+	if sn, ok := client.(Snapshotter); ok {
+		state, err := sn.SnapshotState()
+		if err != nil {
+			return cs, fmt.Errorf("snapshot client %s: %w", cs.ID, err)
+		}
+		cs.State = state
+	}
+	return cs, nil
+}
+
+// RestoreState carrega um snapshot gerado por SnapshotState. Cada estado é
+// aplicado quando um client com o mesmo ID conectar, antes do OnConn.
+func (s *Server[T, M]) RestoreState(data []byte) error {
+	var snap serverSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+	s.restored.mu.Lock()
+	defer s.restored.mu.Unlock()
+	if s.restored.clients == nil {
+		s.restored.clients = make(map[string]ClientSnapshot)
+	}
+	for _, cs := range snap.Clients {
+		s.restored.clients[cs.ID] = cs
+	}
+	return nil
+}
+
+// takeRestored remove e retorna o estado restaurado do ID, se houver
+func (s *Server[T, M]) takeRestored(id string) (ClientSnapshot, bool) {
+	s.restored.mu.Lock()
+	defer s.restored.mu.Unlock()
+	cs, ok := s.restored.clients[id]
+	if ok {
+		delete(s.restored.clients, id)
+	}
+	return cs, ok
+}
+
+// applyRestored aplica o estado restaurado ao client recém-conectado
+func (s *Server[T, M]) applyRestored(conn *Conn, client T) error {
+	ci, ok := any(client).(ClientInterface)
+	if !ok || ci.GetID() == "" {
+		return nil
+	}
+	cs, ok := s.takeRestored(ci.GetID())
+	if !ok {
+		return nil
+	}
+	for k, v := range cs.Meta {
+		ci.SetMeta(k, v)
+	}
+	for _, tag := range cs.Tags {
+		conn.tagIndex().add(conn, tag)
+	}
+	if r, ok := any(client).(RoomSetter); ok && cs.Room != "" {
+		r.SetRoom(cs.Room)
+	}
+	if sn, ok := any(client).(Snapshotter); ok && len(cs.State) > 0 {
+		if err := sn.RestoreState(cs.State); err != nil {
+			return fmt.Errorf("restore client %s: %w", cs.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package server
+
+import (
+	"errors"
+	"net"
+)
+
+const reusePortSupported = false
+
+func listenReusePort(addr *net.UDPAddr) (net.PacketConn, error) {
+	return nil, errors.New("SO_REUSEPORT not supported")
+}
+
+func socketBufferSizes(conn *net.UDPConn) (read, write int, err error) {
+	return 0, 0, errors.New("reading socket buffer sizes not supported")
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"math"
+	"sync"
+)
+
+// Positioned é implementado por clients que possuem posição no mundo
+type Positioned interface {
+	Position() (x, y, z float64)
+}
+
+// Point representa uma posição 3D
+type Point struct {
+	X, Y, Z float64
+}
+
+func (p Point) distSq(o Point) float64 {
+	dx, dy, dz := p.X-o.X, p.Y-o.Y, p.Z-o.Z
+	return dx*dx + dy*dy + dz*dz
+}
+
+type cellKey struct {
+	x, y, z int64
+}
+
+type gridEntry[K comparable] struct {
+	key K
+	pos Point
+}
+
+// spatialGrid é um índice espacial de grade uniforme
+type spatialGrid[K comparable] struct {
+	cellSize float64
+	cells    map[cellKey][]gridEntry[K]
+	// min e max delimitam as células ocupadas, para limitar as consultas
+	min, max cellKey
+}
+
+func newSpatialGrid[K comparable](cellSize float64) *spatialGrid[K] {
+	return &spatialGrid[K]{
+		cellSize: cellSize,
+		cells:    make(map[cellKey][]gridEntry[K]),
+	}
+}
+
+// maxCellCoord limita as coordenadas de célula para que a conversão de
+// posições enormes ou infinitas para int64 não transborde
+const maxCellCoord = 1 << 62
+
+func (g *spatialGrid[K]) cellOf(p Point) cellKey {
+	return cellKey{
+		x: g.cellCoord(p.X),
+		y: g.cellCoord(p.Y),
+		z: g.cellCoord(p.Z),
+	}
+}
+
+func (g *spatialGrid[K]) cellCoord(v float64) int64 {
+	c := math.Floor(v / g.cellSize)
+	switch {
+	case c >= maxCellCoord:
+		return maxCellCoord
+	case c <= -maxCellCoord:
+		return -maxCellCoord
+	case math.IsNaN(c):
+		return 0
+	}
+	return int64(c)
+}
+
+func (g *spatialGrid[K]) insert(key K, p Point) {
+	c := g.cellOf(p)
+	if len(g.cells) == 0 {
+		g.min, g.max = c, c
+	} else {
+		g.min = cellKey{min(g.min.x, c.x), min(g.min.y, c.y), min(g.min.z, c.z)}
+		g.max = cellKey{max(g.max.x, c.x), max(g.max.y, c.y), max(g.max.z, c.z)}
+	}
+	g.cells[c] = append(g.cells[c], gridEntry[K]{key: key, pos: p})
+}
+
+// query chama fn para cada entrada dentro do raio a partir de origin. A caixa
+// de células é limitada às células ocupadas; se ainda assim tiver mais células
+// que as ocupadas, as células ocupadas são percorridas diretamente.
+func (g *spatialGrid[K]) query(origin Point, radius float64, fn func(key K, pos Point)) {
+	if len(g.cells) == 0 || !(radius >= 0) {
+		return
+	}
+	lo := g.cellOf(Point{origin.X - radius, origin.Y - radius, origin.Z - radius})
+	hi := g.cellOf(Point{origin.X + radius, origin.Y + radius, origin.Z + radius})
+	lo = cellKey{max(lo.x, g.min.x), max(lo.y, g.min.y), max(lo.z, g.min.z)}
+	hi = cellKey{min(hi.x, g.max.x), min(hi.y, g.max.y), min(hi.z, g.max.z)}
+	if lo.x > hi.x || lo.y > hi.y || lo.z > hi.z {
+		return
+	}
+	r2 := radius * radius
+	visit := func(entries []gridEntry[K]) {
+		for _, e := range entries {
+			if e.pos.distSq(origin) <= r2 {
+				fn(e.key, e.pos)
+			}
+		}
+	}
+	// Em float64 para que o produto não transborde
+	box := (float64(hi.x-lo.x) + 1) * (float64(hi.y-lo.y) + 1) * (float64(hi.z-lo.z) + 1)
+	if box > float64(len(g.cells)) {
+		for c, entries := range g.cells {
+			if c.x >= lo.x && c.x <= hi.x && c.y >= lo.y && c.y <= hi.y && c.z >= lo.z && c.z <= hi.z {
+				visit(entries)
+			}
+		}
+		return
+	}
+	for x := lo.x; x <= hi.x; x++ {
+		for y := lo.y; y <= hi.y; y++ {
+			for z := lo.z; z <= hi.z; z++ {
+				visit(g.cells[cellKey{x, y, z}])
+			}
+		}
+	}
+}
+
+// spatialIndex guarda a grade de clients reconstruída a cada tick
+type spatialIndex struct {
+	mu   sync.RWMutex
+	grid *spatialGrid[*Conn]
+}
+
+// RebuildSpatialIndex reconstrói o índice espacial com a posição atual dos clients.
+// É chamado automaticamente antes de cada tick quando WithSpatialCellSize é usado.
+func (s *Server[T, M]) RebuildSpatialIndex() {
+	if s.opts.spatialCellSize <= 0 {
+		return
+	}
+	grid := newSpatialGrid[*Conn](s.opts.spatialCellSize)
+	s.conns.Range(func(conn *Conn, c T) bool {
+		if p, ok := any(c).(Positioned); ok {
+			x, y, z := p.Position()
+			grid.insert(conn, Point{x, y, z})
+		}
+		return true
+	})
+	s.spatial.mu.Lock()
+	s.spatial.grid = grid
+	s.spatial.mu.Unlock()
+}
+
+// BroadcastNearby envia a mensagem apenas para clients Positioned dentro do raio.
+// Usa o índice espacial quando configurado, ou percorre todos os clients caso contrário.
+func (s *Server[T, M]) BroadcastNearby(origin Point, radius float64, msg *Message) error {
+	s.spatial.mu.RLock()
+	grid := s.spatial.grid
+	s.spatial.mu.RUnlock()
+	if grid == nil {
+		r2 := radius * radius
+		return s.BroadcastWhere(msg, func(c T) bool {
+			p, ok := any(c).(Positioned)
+			if !ok {
+				return false
+			}
+			x, y, z := p.Position()
+			return origin.distSq(Point{x, y, z}) <= r2
+		})
+	}
+
+	data, buf, err := s.marshalBroadcast(msg)
+	if err != nil {
+		return err
+	}
+	defer releaseBuffer(buf)
+	var targets []broadcastTarget
+	grid.query(origin, radius, func(conn *Conn, _ Point) {
+		if client, ok := s.conns.Load(conn); ok {
+			targets = append(targets, broadcastTarget{conn: conn, id: clientID(conn, client)})
+		}
+	})
+	return s.deliver(targets, data, (*Conn).sendUnreliableRaw)
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func queryKeys(g *spatialGrid[string], origin Point, radius float64) []string {
+	var keys []string
+	g.query(origin, radius, func(key string, _ Point) { keys = append(keys, key) })
+	sort.Strings(keys)
+	return keys
+}
+
+func TestSpatialGridQuery(t *testing.T) {
+	g := newSpatialGrid[string](10)
+	g.insert("a", Point{1, 1, 0})
+	g.insert("b", Point{15, 0, 0})
+	g.insert("c", Point{100, 100, 0})
+
+	if got := queryKeys(g, Point{}, 20); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("radius 20 = %v, want [a b]", got)
+	}
+	if got := queryKeys(g, Point{}, 5); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("radius 5 = %v, want [a]", got)
+	}
+}
+
+func TestSpatialGridQueryHugeRadius(t *testing.T) {
+	g := newSpatialGrid[string](1)
+	g.insert("a", Point{0, 0, 0})
+	g.insert("b", Point{1e6, -1e6, 1e6})
+	g.insert("far", Point{1e300, 0, math.Inf(-1)})
+
+	for _, radius := range []float64{1e12, math.MaxFloat64, math.Inf(1)} {
+		if got := queryKeys(g, Point{}, radius); len(got) < 2 || got[0] != "a" || got[1] != "b" {
+			t.Fatalf("radius %g = %v, want a and b", radius, got)
+		}
+	}
+	if got := queryKeys(g, Point{1e300, 0, 0}, 1); len(got) != 0 {
+		t.Fatalf("query far from everything = %v", got)
+	}
+	if got := queryKeys(g, Point{}, math.NaN()); len(got) != 0 {
+		t.Fatalf("NaN radius = %v", got)
+	}
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Tipos de mensagem enviados pelo StateSync
+const (
+	MessageTypeStateFull  = "state_full"
+	MessageTypeStateDelta = "state_delta"
+)
+
+// StateSync envia para cada client apenas os campos do estado S que mudaram
+// desde o último envio, com keyframes completos periódicos. O delta é um mapa
+// campo -> valor serializado pelo Codec do servidor. O último estado enviado é
+// guardado como cópia profunda, então S pode compartilhar slices, maps e
+// ponteiros com o estado do jogo (mas não pode ter ciclos). Quando S não é uma
+// struct não há campos para comparar e cada mudança envia o estado completo.
+type StateSync[S, T, M any] struct {
+	server           *Server[T, M]
+	keyframeInterval int
+
+	mu    sync.Mutex
+	tick  int
+	state map[*Conn]S
+}
+
+// NewStateSync cria um StateSync para o servidor. keyframeInterval define a cada
+// quantos envios um estado completo é enviado (0 = apenas no primeiro envio).
+func NewStateSync[S, T, M any](s *Server[T, M], keyframeInterval int) *StateSync[S, T, M] {
+	return &StateSync[S, T, M]{
+		server:           s,
+		keyframeInterval: keyframeInterval,
+		state:            make(map[*Conn]S),
+	}
+}
+
+// BroadcastDelta calcula o estado de cada client com compute e envia o delta
+// em relação ao último estado enviado para aquele client
+func (ss *StateSync[S, T, M]) BroadcastDelta(compute func(c T) S) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.tick++
+	keyframe := ss.keyframeInterval > 0 && ss.tick%ss.keyframeInterval == 0
+	seen := make(map[*Conn]struct{})
+	type pending struct {
+		conn    *Conn
+		id      string
+		msg     *Message
+		payload any
+		cur     S
+	}
+	var out []pending
+
+	// Os estados são calculados antes dos envios para que, com
+	// WithOrderedBroadcasts, a trava de ordem não envolva o compute
+	ss.server.conns.Range(func(conn *Conn, client T) bool {
+		seen[conn] = struct{}{}
+		cur := compute(client)
+		prev, hasPrev := ss.state[conn]
+
+		msg := &Message{Type: MessageTypeStateDelta}
+		var payload any
+		if !hasPrev || keyframe {
+			msg.Type = MessageTypeStateFull
+			payload = cur
+		} else {
+			changed, full := diffFields(prev, cur)
+			switch {
+			case full:
+				msg.Type = MessageTypeStateFull
+				payload = cur
+			case len(changed) == 0:
+				return true
+			default:
+				payload = changed
+			}
+		}
+		out = append(out, pending{conn: conn, id: clientID(conn, client), msg: msg, payload: payload, cur: cur})
+		return true
+	})
+
+	var errs []error
+	unlock := ss.server.orderBroadcasts()
+	for _, p := range out {
+		if err := ss.send(p.conn, p.msg, p.payload); err != nil {
+			errs = append(errs, fmt.Errorf("client %s: %w", p.id, err))
+			continue
+		}
+		ss.state[p.conn] = deepCopy(p.cur)
+	}
+	unlock()
+
+	// Remove o estado de clients desconectados
+	for conn := range ss.state {
+		if _, ok := seen[conn]; !ok {
+			delete(ss.state, conn)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (ss *StateSync[S, T, M]) send(conn *Conn, msg *Message, payload any) error {
+	codec := ss.server.opts.codec
+	d, err := codec.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	msg.Data = d
+	data, err := codec.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return conn.sendRaw(data)
+}
+
+// diffFields retorna os campos exportados de cur que diferem de prev. Tipos
+// que não são structs não têm campos: full indica que cur mudou por inteiro.
+func diffFields(prev, cur any) (changed map[string]any, full bool) {
+	pv, cv := reflect.ValueOf(prev), reflect.ValueOf(cur)
+	for pv.Kind() == reflect.Pointer && cv.Kind() == reflect.Pointer {
+		if pv.IsNil() || cv.IsNil() {
+			break
+		}
+		pv, cv = pv.Elem(), cv.Elem()
+	}
+	if cv.Kind() != reflect.Struct || pv.Type() != cv.Type() {
+		return nil, !reflect.DeepEqual(prev, cur)
+	}
+	changed = make(map[string]any)
+	t := cv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := fieldName(f)
+		if name == "-" {
+			continue
+		}
+		a, b := pv.Field(i).Interface(), cv.Field(i).Interface()
+		if !reflect.DeepEqual(a, b) {
+			changed[name] = b
+		}
+	}
+	return changed, false
+}
+
+// deepCopy copia v sem compartilhar slices, maps e ponteiros, para que
+// alterações feitas no estado original apareçam no próximo diffFields.
+// Campos não exportados são copiados por valor, como numa atribuição.
+func deepCopy[S any](v S) S {
+	rv := reflect.ValueOf(&v).Elem()
+	rv.Set(copyValue(rv))
+	return v
+}
+
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.New(v.Type().Elem())
+		n.Elem().Set(copyValue(v.Elem()))
+		return n
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.New(v.Type()).Elem()
+		n.Set(copyValue(v.Elem()))
+		return n
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			n.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return n
+	case reflect.Array:
+		n := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			n.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return n
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			n.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+		return n
+	case reflect.Struct:
+		n := reflect.New(v.Type()).Elem()
+		n.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if n.Field(i).CanSet() {
+				n.Field(i).Set(copyValue(v.Field(i)))
+			}
+		}
+		return n
+	default:
+		return v
+	}
+}
+
+// fieldName usa a tag json do campo, se existir, para manter os nomes do estado completo
+func fieldName(f reflect.StructField) string {
+	if tag, ok := f.Tag.Lookup("json"); ok {
+		name, _, _ := strings.Cut(tag, ",")
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
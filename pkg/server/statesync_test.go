@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type syncState struct {
+	X     int      `json:"x"`
+	HP    int      `json:"hp"`
+	Items []string `json:"items"`
+}
+
+// receiveState espera a próxima mensagem do StateSync e decodifica o payload
+func receiveState(t *testing.T, ctx context.Context, tc *TestClient, wantType string) map[string]json.RawMessage {
+	t.Helper()
+	msg, err := tc.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != wantType {
+		t.Fatalf("message type = %q, want %q", msg.Type, wantType)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Data, &fields); err != nil {
+		t.Fatalf("payload %s: %v", msg.Data, err)
+	}
+	return fields
+}
+
+func TestStateSyncSendsOnlyChangedFields(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithFraming(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	t.Cleanup(s.Stop)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc := connectTestClients(t, ctx, s, 1)[0]
+
+	state := &syncState{X: 1, HP: 100, Items: []string{"sword"}}
+	ss := NewStateSync[syncState](s, 0)
+	compute := func(*Client) syncState { return *state }
+
+	if err := ss.BroadcastDelta(compute); err != nil {
+		t.Fatal(err)
+	}
+	if full := receiveState(t, ctx, tc, MessageTypeStateFull); len(full) != 3 {
+		t.Fatalf("full state = %v", full)
+	}
+
+	state.HP = 90
+	if err := ss.BroadcastDelta(compute); err != nil {
+		t.Fatal(err)
+	}
+	delta := receiveState(t, ctx, tc, MessageTypeStateDelta)
+	if len(delta) != 1 || string(delta["hp"]) != "90" {
+		t.Fatalf("delta = %v, want only hp", delta)
+	}
+
+	// Alteração no lugar: o slice é compartilhado com o estado do jogo
+	state.Items[0] = "axe"
+	if err := ss.BroadcastDelta(compute); err != nil {
+		t.Fatal(err)
+	}
+	delta = receiveState(t, ctx, tc, MessageTypeStateDelta)
+	if len(delta) != 1 || string(delta["items"]) != `["axe"]` {
+		t.Fatalf("delta = %v, want only items", delta)
+	}
+}
+
+func TestStateSyncNonStructSendsFullState(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage, WithFraming(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	t.Cleanup(s.Stop)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc := connectTestClients(t, ctx, s, 1)[0]
+
+	scores := map[string]int{"ana": 1}
+	ss := NewStateSync[map[string]int](s, 0)
+	compute := func(*Client) map[string]int { return scores }
+
+	if err := ss.BroadcastDelta(compute); err != nil {
+		t.Fatal(err)
+	}
+	receiveState(t, ctx, tc, MessageTypeStateFull)
+
+	scores["ana"] = 2
+	if err := ss.BroadcastDelta(compute); err != nil {
+		t.Fatal(err)
+	}
+	if full := receiveState(t, ctx, tc, MessageTypeStateFull); string(full["ana"]) != "2" {
+		t.Fatalf("full state = %v", full)
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaxCountedMessageTypes limita quantos tipos distintos o MessagesByType guarda;
+// os tipos além do limite são somados em OtherMessageTypes
+const MaxCountedMessageTypes = 256
+
+// OtherMessageTypes é a chave do MessagesByType que soma os tipos além do limite
+const OtherMessageTypes = "_other"
+
+// Stats é um retrato do estado de execução do servidor
+type Stats struct {
+	// Connections é o número de conexões ativas
+	Connections int
+	// TicksBehind é quantos ticks foram descartados na última iteração do
+	// passo fixo por exceder o limite de recuperação
+	TicksBehind int
+	// TickOverruns é o total de ticks que levaram mais que o intervalo do tick
+	TickOverruns int64
+	// AvgRTT é o RTT médio das conexões ativas
+	AvgRTT time.Duration
+	// AvgLossRate, AvgCongestionWindow e AvgBytesInFlight são médias de ConnStats
+	AvgLossRate         float64
+	AvgCongestionWindow int
+	AvgBytesInFlight    int
+	// TickRate é a taxa de ticks por segundo efetiva, ajustada por WithAdaptiveTick
+	TickRate int
+	// DroppedMessages é o total de mensagens descartadas por filas de saída cheias
+	DroppedMessages int64
+	// DroppedEvents é o total de eventos descartados pelo Events com EventDropOldest
+	DroppedEvents int64
+	// MessagesByType é o total de mensagens recebidas, admitidas pelo limite de
+	// mensagens e decodificadas, por tipo
+	MessagesByType map[string]uint64
+}
+
+// Stats retorna as estatísticas atuais do servidor
+func (s *Server[T, M]) Stats() Stats {
+	st := Stats{
+		Connections:     int(s.connCount.Load()),
+		TicksBehind:     int(s.ticksBehind.Load()),
+		TickOverruns:    s.tickOverruns.Load(),
+		TickRate:        s.TickRate(),
+		DroppedMessages: s.dropped.Load(),
+		DroppedEvents:   s.events.dropped.Load(),
+		MessagesByType:  s.typeCounts.snapshot(),
+	}
+	s.aggregateConnStats(&st)
+	return st
+}
+
+// typeCounters conta as mensagens recebidas por tipo. O tipo vem do client, então
+// o número de chaves é limitado para um client não inflar o mapa.
+type typeCounters struct {
+	mu     sync.RWMutex
+	counts map[string]*atomic.Uint64
+	other  atomic.Uint64
+}
+
+func (tc *typeCounters) add(msgType string) {
+	tc.mu.RLock()
+	n, ok := tc.counts[msgType]
+	tc.mu.RUnlock()
+	if !ok {
+		tc.mu.Lock()
+		if n, ok = tc.counts[msgType]; !ok && len(tc.counts) < MaxCountedMessageTypes {
+			if tc.counts == nil {
+				tc.counts = make(map[string]*atomic.Uint64)
+			}
+			n = new(atomic.Uint64)
+			tc.counts[msgType] = n
+		}
+		tc.mu.Unlock()
+	}
+	if n == nil {
+		tc.other.Add(1)
+		return
+	}
+	n.Add(1)
+}
+
+func (tc *typeCounters) snapshot() map[string]uint64 {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	out := make(map[string]uint64, len(tc.counts)+1)
+	for t, n := range tc.counts {
+		out[t] = n.Load()
+	}
+	if n := tc.other.Load(); n > 0 {
+		out[OtherMessageTypes] = n
+	}
+	return out
+}
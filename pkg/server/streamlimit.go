@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTooManyStreams indica que o client excedeu o limite de WithMaxStreamsPerConn
+var ErrTooManyStreams = errors.New("too many streams")
+
+// StreamLimitPolicy define o que acontece quando um client abre streams além do limite
+type StreamLimitPolicy int
+
+const (
+	// StreamLimitBlock para de aceitar streams do client até alguma terminar
+	StreamLimitBlock StreamLimitPolicy = iota
+	// StreamLimitDisconnect encerra a conexão do client
+	StreamLimitDisconnect
+)
+
+// WithMaxStreamsPerConn limita as streams de entrada sendo processadas ao mesmo
+// tempo por conexão, cada uma com sua goroutine (0 = sem limite). O
+// MaxIncomingStreams do QUIC é ajustado para n, então o próprio client não
+// consegue abrir mais; com StreamLimitDisconnect ele fica em n+1 para o excesso
+// chegar ao servidor e derrubar a conexão.
+func WithMaxStreamsPerConn(n int) Option {
+	return func(o *options) {
+		o.maxStreamsPerConn = n
+	}
+}
+
+// WithStreamLimitPolicy define se streams além do limite esperam ou derrubam a conexão
+func WithStreamLimitPolicy(p StreamLimitPolicy) Option {
+	return func(o *options) {
+		o.streamLimitPolicy = p
+	}
+}
+
+// applyStreamLimit ajusta o MaxIncomingStreams do QUIC ao limite por conexão
+func (o *options) applyStreamLimit() {
+	if o.maxStreamsPerConn <= 0 {
+		return
+	}
+	limit := int64(o.maxStreamsPerConn)
+	if o.streamLimitPolicy == StreamLimitDisconnect {
+		limit++
+	}
+	o.quicConfig.MaxIncomingStreams = limit
+}
+
+// acquireStream reserva uma vaga para a stream aceita. Retorna false se a
+// conexão terminou enquanto esperava ou foi derrubada por exceder o limite.
+func (s *Server[T, M]) acquireStream(ctx context.Context, conn *Conn) bool {
+	if conn.streamSlots == nil {
+		return true
+	}
+	if s.opts.streamLimitPolicy == StreamLimitDisconnect {
+		select {
+		case conn.streamSlots <- struct{}{}:
+			return true
+		default:
+			conn.closeWithReason(ReasonKicked, CloseCodeTooManyStreams, ErrTooManyStreams.Error())
+			return false
+		}
+	}
+	select {
+	case conn.streamSlots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// releaseStream libera a vaga da stream
+func (c *Conn) releaseStream() {
+	if c.streamSlots != nil {
+		<-c.streamSlots
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"sort"
+	"sync"
+)
+
+// tagIndex mantém o mapeamento tag -> conexões, compartilhado pelo servidor
+type tagIndex struct {
+	mu     sync.RWMutex
+	byTag  map[string]map[*Conn]struct{}
+	byConn map[*Conn]map[string]struct{}
+}
+
+func newTagIndex() *tagIndex {
+	return &tagIndex{
+		byTag:  make(map[string]map[*Conn]struct{}),
+		byConn: make(map[*Conn]map[string]struct{}),
+	}
+}
+
+func (ix *tagIndex) add(conn *Conn, tag string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.byTag[tag] == nil {
+		ix.byTag[tag] = make(map[*Conn]struct{})
+	}
+	ix.byTag[tag][conn] = struct{}{}
+	if ix.byConn[conn] == nil {
+		ix.byConn[conn] = make(map[string]struct{})
+	}
+	ix.byConn[conn][tag] = struct{}{}
+}
+
+func (ix *tagIndex) remove(conn *Conn, tag string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeLocked(conn, tag)
+}
+
+func (ix *tagIndex) removeLocked(conn *Conn, tag string) {
+	if conns := ix.byTag[tag]; conns != nil {
+		delete(conns, conn)
+		if len(conns) == 0 {
+			delete(ix.byTag, tag)
+		}
+	}
+	if tags := ix.byConn[conn]; tags != nil {
+		delete(tags, tag)
+		if len(tags) == 0 {
+			delete(ix.byConn, conn)
+		}
+	}
+}
+
+// removeConn remove todas as tags da conexão
+func (ix *tagIndex) removeConn(conn *Conn) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for tag := range ix.byConn[conn] {
+		ix.removeLocked(conn, tag)
+	}
+}
+
+func (ix *tagIndex) has(conn *Conn, tag string) bool {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	_, ok := ix.byConn[conn][tag]
+	return ok
+}
+
+func (ix *tagIndex) tagsOf(conn *Conn) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	tags := make([]string, 0, len(ix.byConn[conn]))
+	for tag := range ix.byConn[conn] {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (ix *tagIndex) conns(tag string) []*Conn {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	conns := make([]*Conn, 0, len(ix.byTag[tag]))
+	for conn := range ix.byTag[tag] {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// tagIndex retorna o índice de tags da conexão, criando um local se a conexão
+// não foi criada pelo servidor
+func (c *Conn) tagIndex() *tagIndex {
+	c.tagsOnce.Do(func() {
+		if c.tags == nil {
+			c.tags = newTagIndex()
+		}
+	})
+	return c.tags
+}
+
+// AddTag adiciona uma tag ao client (ex.: "team:red", "admin")
+func (c *Client) AddTag(tag string) {
+	c.Conn.tagIndex().add(c.Conn, tag)
+}
+
+// RemoveTag remove uma tag do client
+func (c *Client) RemoveTag(tag string) {
+	c.Conn.tagIndex().remove(c.Conn, tag)
+}
+
+// HasTag informa se o client possui a tag
+func (c *Client) HasTag(tag string) bool {
+	return c.Conn.tagIndex().has(c.Conn, tag)
+}
+
+// GetTags retorna as tags do client em ordem alfabética
+func (c *Client) GetTags() []string {
+	return c.Conn.tagIndex().tagsOf(c.Conn)
+}
+
+// BroadcastToTag envia a mensagem apenas para os clients com a tag, em O(clients com a tag)
+func (s *Server[T, M]) BroadcastToTag(tag string, msg *Message) error {
+	data, buf, err := s.marshalBroadcast(msg)
+	if err != nil {
+		return err
+	}
+	defer releaseBuffer(buf)
+	var targets []broadcastTarget
+	for _, conn := range s.tags.conns(tag) {
+		if client, ok := s.conns.Load(conn); ok {
+			targets = append(targets, broadcastTarget{conn: conn, id: clientID(conn, client)})
+		}
+	}
+	return s.deliver(targets, data, (*Conn).sendUnreliableRaw)
+}
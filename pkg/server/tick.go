@@ -0,0 +1,128 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+func (s *Server[T, M]) tickLoop() {
+	defer s.wg.Done()
+	tps := s.tickInterval()
+	ticker := time.NewTicker(tps)
+	defer ticker.Stop()
+	last := time.Now()
+	var acc time.Duration
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.tickReset:
+			tps = s.tickInterval()
+			ticker.Reset(tps)
+		case now := <-ticker.C:
+			elapsed := now.Sub(last)
+			last = now
+			if s.tickPaused.Load() {
+				// O índice espacial continua atualizado para BroadcastNearby; o tempo
+				// pausado não é acumulado para o dt da retomada
+				s.RebuildSpatialIndex()
+				s.flushTickBatch()
+				acc = 0
+				continue
+			}
+			if s.opts.maxCatchUpTicks <= 0 {
+				s.runTick(elapsed)
+				continue
+			}
+
+			// Passo fixo: executa quantos ticks forem necessários para alcançar
+			// a taxa alvo, limitado a maxCatchUpTicks para evitar a espiral da morte
+			acc += elapsed
+			steps := 0
+			for acc >= tps && steps < s.opts.maxCatchUpTicks {
+				s.runTick(tps)
+				acc -= tps
+				steps++
+			}
+			behind := int64(acc / tps)
+			s.ticksBehind.Store(behind)
+			if behind > 0 {
+				acc -= time.Duration(behind) * tps
+			}
+		}
+	}
+}
+
+func (s *Server[T, M]) runTick(dt time.Duration) {
+	start := time.Now()
+	s.RebuildSpatialIndex()
+	if s.TickFn != nil {
+		s.TickFn(s, dt)
+	}
+	s.flushTickBatch()
+	took := time.Since(start)
+	s.checkOverrun(took)
+	s.observeTick(took)
+}
+
+// tickOverrunLogInterval limita o aviso de tick atrasado a um por intervalo
+const tickOverrunLogInterval = time.Second
+
+// checkOverrun conta e avisa quando o tick passou do intervalo. O log agrupa os
+// atrasos de cada segundo para não inundar a saída a 60 ticks por segundo.
+func (s *Server[T, M]) checkOverrun(took time.Duration) {
+	budget := s.tickInterval()
+	if took <= budget {
+		return
+	}
+	s.tickOverruns.Add(1)
+	s.overrunsSinceLog++
+	if now := time.Now(); now.Sub(s.overrunLog) >= tickOverrunLogInterval {
+		log.Printf("tick overrun: took %s, budget %s (%d overruns since last warning)\n", took, budget, s.overrunsSinceLog)
+		s.overrunLog, s.overrunsSinceLog = now, 0
+	}
+	if s.OnTickOverrun != nil {
+		s.OnTickOverrun(took, budget)
+	}
+}
+
+// PauseTick congela a simulação: o TickFn deixa de ser chamado, mas conexões e
+// mensagens continuam sendo processadas. Pode ser chamado de dentro do OnMsg ou do TickFn.
+func (s *Server[T, M]) PauseTick() {
+	s.tickPaused.Store(true)
+}
+
+// ResumeTick volta a chamar o TickFn. O primeiro dt após a retomada é um intervalo normal.
+func (s *Server[T, M]) ResumeTick() {
+	s.tickPaused.Store(false)
+}
+
+// IsPaused informa se o tick está pausado
+func (s *Server[T, M]) IsPaused() bool {
+	return s.tickPaused.Load()
+}
+
+// SetTickRate altera quantos ticks por segundo o TickFn é chamado, inclusive com
+// o servidor rodando. O ticker é reajustado sem parar o loop; no passo fixo o dt
+// passa a ser o novo intervalo.
+func (s *Server[T, M]) SetTickRate(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("%w: tick rate %d", ErrInvalidOption, n)
+	}
+	s.tps.Store(int64(time.Second / time.Duration(n)))
+	select {
+	case s.tickReset <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// TickRate retorna a taxa de ticks por segundo atual
+func (s *Server[T, M]) TickRate() int {
+	return int(time.Second / s.tickInterval())
+}
+
+func (s *Server[T, M]) tickInterval() time.Duration {
+	return time.Duration(s.tps.Load())
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// MessageTypeTimeSync é o tipo usado tanto no ping do client quanto na resposta do servidor
+const MessageTypeTimeSync = "timesync"
+
+// TimeSyncRequest é o ping de sincronização enviado pelo client
+type TimeSyncRequest struct {
+	// ClientTime é o horário de envio no client em nanossegundos Unix
+	ClientTime int64 `json:"client_time"`
+}
+
+// TimeSyncResponse é a resposta do servidor, ecoando o horário do client
+type TimeSyncResponse struct {
+	ClientTime int64 `json:"client_time"`
+	ServerTime int64 `json:"server_time"`
+}
+
+// TimeSyncResult é a estimativa de relógio calculada pelo client
+type TimeSyncResult struct {
+	// Offset somado ao relógio local resulta no horário estimado do servidor
+	Offset time.Duration
+	RTT    time.Duration
+}
+
+// EnableTimeSync faz o servidor responder às mensagens timesync com o seu horário
+func (s *Server[T, M]) EnableTimeSync() {
+	s.timeSync.Store(true)
+}
+
+// handleTimeSync responde ao ping com o horário atual do servidor
+func (s *Server[T, M]) handleTimeSync(conn *Conn, msg *Message) {
+	var req TimeSyncRequest
+	if err := json.Unmarshal(msg.Data, &req); err != nil {
+		log.Println("invalid timesync request:", err)
+		return
+	}
+	data, err := s.encode(MessageTypeTimeSync, TimeSyncResponse{
+		ClientTime: req.ClientTime,
+		ServerTime: time.Now().UnixNano(),
+	})
+	if err != nil {
+		log.Println("timesync encode error:", err)
+		return
+	}
+	if err := conn.sendRaw(data); err != nil {
+		log.Println("timesync reply error:", err)
+	}
+}
+
+// NewTimeSyncRequest cria o ping de sincronização com o horário atual do client
+func NewTimeSyncRequest() *Message {
+	data, _ := json.Marshal(TimeSyncRequest{ClientTime: time.Now().UnixNano()})
+	return &Message{Type: MessageTypeTimeSync, Data: data}
+}
+
+// ComputeTimeSync calcula offset e RTT a partir da resposta recebida em received.
+// Assume que o caminho de ida e o de volta têm a mesma latência.
+func ComputeTimeSync(resp TimeSyncResponse, received time.Time) TimeSyncResult {
+	rtt := time.Duration(received.UnixNano() - resp.ClientTime)
+	serverNow := time.Duration(resp.ServerTime) + rtt/2
+	return TimeSyncResult{
+		Offset: serverNow - time.Duration(received.UnixNano()),
+		RTT:    rtt,
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"slices"
+)
+
+// WithMinTLSVersion define a versão mínima de TLS aceita pelo listener.
+// O QUIC sempre negocia TLS 1.3, então versões acima dele são rejeitadas pelo New.
+func WithMinTLSVersion(v uint16) Option {
+	return func(o *options) {
+		o.minTLSVersion = v
+	}
+}
+
+// WithCipherSuites restringe as cipher suites aceitas. Como o QUIC usa TLS 1.3,
+// apenas suites TLS 1.3 são válidas. O crypto/tls não permite escolher a ordem
+// dessas suites, então handshakes que negociarem uma suite fora da lista são recusados.
+func WithCipherSuites(suites []uint16) Option {
+	return func(o *options) {
+		o.cipherSuites = suites
+	}
+}
+
+// tls13Suites são as cipher suites definidas para TLS 1.3
+var tls13Suites = []uint16{
+	tls.TLS_AES_128_GCM_SHA256,
+	tls.TLS_AES_256_GCM_SHA384,
+	tls.TLS_CHACHA20_POLY1305_SHA256,
+}
+
+// validateTLSPolicy verifica se a política TLS é compatível com QUIC
+func (o options) validateTLSPolicy() error {
+	switch o.minTLSVersion {
+	case 0, tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13:
+	default:
+		return fmt.Errorf("%w: unsupported min TLS version %#04x: QUIC requires TLS 1.3", ErrInvalidOption, o.minTLSVersion)
+	}
+	for _, id := range o.cipherSuites {
+		if !slices.Contains(tls13Suites, id) {
+			return fmt.Errorf("%w: cipher suite %s is not a TLS 1.3 suite and cannot be used with QUIC", ErrInvalidOption, tls.CipherSuiteName(id))
+		}
+	}
+	return nil
+}
+
+// applyTLSPolicy aplica a versão mínima e as cipher suites permitidas ao tls.Config
+func applyTLSPolicy(conf *tls.Config, o options) *tls.Config {
+	if o.minTLSVersion == 0 && len(o.cipherSuites) == 0 {
+		return conf
+	}
+	conf = conf.Clone()
+	if o.minTLSVersion != 0 {
+		conf.MinVersion = o.minTLSVersion
+	}
+	if len(o.cipherSuites) > 0 {
+		allowed := slices.Clone(o.cipherSuites)
+		conf.CipherSuites = allowed
+		verify := conf.VerifyConnection
+		conf.VerifyConnection = func(cs tls.ConnectionState) error {
+			if !slices.Contains(allowed, cs.CipherSuite) {
+				return fmt.Errorf("cipher suite %s not allowed", tls.CipherSuiteName(cs.CipherSuite))
+			}
+			if verify != nil {
+				return verify(cs)
+			}
+			return nil
+		}
+	}
+	return conf
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync/atomic"
+)
+
+// certStore guarda o certificado trocado em tempo de execução por ReloadTLS
+type certStore struct {
+	cert atomic.Pointer[tls.Certificate]
+}
+
+// applyCertReload faz o tls.Config buscar o certificado no store a cada handshake.
+// Os certificados configurados continuam valendo até o primeiro ReloadTLS.
+func applyCertReload(conf *tls.Config, store *certStore) *tls.Config {
+	conf = conf.Clone()
+	certs := conf.Certificates
+	getCert := conf.GetCertificate
+	// O crypto/tls ignora GetCertificate quando há Certificates e o client não envia SNI
+	conf.Certificates = nil
+	conf.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if c := store.cert.Load(); c != nil {
+			return c, nil
+		}
+		if getCert != nil {
+			if c, err := getCert(hello); c != nil || err != nil {
+				return c, err
+			}
+		}
+		for i := range certs {
+			if hello.SupportsCertificate(&certs[i]) == nil {
+				return &certs[i], nil
+			}
+		}
+		if len(certs) > 0 {
+			return &certs[0], nil
+		}
+		return nil, fmt.Errorf("no certificate configured")
+	}
+	return conf
+}
+
+// ReloadTLS troca o certificado do servidor sem reiniciar. Conexões existentes
+// mantêm a sessão atual; novos handshakes usam o novo certificado.
+func (s *Server[T, M]) ReloadTLS(certPEM, keyPEM []byte) error {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+	s.opts.certs.cert.Store(&cert)
+	return nil
+}
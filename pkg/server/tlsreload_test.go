@@ -0,0 +1,66 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"testing"
+	"time"
+)
+
+func TestReloadTLSKeepsSessionsAndServesNewCert(t *testing.T) {
+	s, err := NewTestServer(NewClient, NewMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := make(chan string, 1)
+	s.OnMsg = func(_ context.Context, _ *Client, msg *Message) { msgs <- msg.Type }
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	old := connectTestClients(t, ctx, s, 1)[0]
+	oldCert := old.Conn.ConnectionState().TLS.PeerCertificates[0].Raw
+
+	certPEM, keyPEM, err := GenerateSelfSigned()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReloadTLS(certPEM, keyPEM); err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(certPEM)
+
+	// A sessão aberta antes da troca continua funcionando nos dois sentidos
+	if err := old.Send(&Message{Type: "still_here"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case typ := <-msgs:
+		if typ != "still_here" {
+			t.Fatalf("OnMsg got %q", typ)
+		}
+	case <-ctx.Done():
+		t.Fatal("existing session stopped delivering messages")
+	}
+	if err := s.BroadcastReliable(&Message{Type: "state"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Receive(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh, err := NewTestClient(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+	newCert := fresh.Conn.ConnectionState().TLS.PeerCertificates[0].Raw
+	if !bytes.Equal(newCert, block.Bytes) {
+		t.Fatal("new handshake did not get the reloaded certificate")
+	}
+	if bytes.Equal(newCert, oldCert) {
+		t.Fatal("certificate did not change")
+	}
+}
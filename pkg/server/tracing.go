@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer cria um span OpenTelemetry por mensagem entregue, do recebimento
+// até o handler retornar, com o tipo da mensagem e o client nos atributos. O
+// span chega ao handler pelo ctx do OnMsg; se o envelope tiver TraceParent, o
+// span continua o trace do client.
+func WithTracer(t trace.Tracer) Option {
+	return func(o *options) {
+		o.tracer = t
+	}
+}
+
+var traceContext = propagation.TraceContext{}
+
+func noopSpanEnd() {}
+
+// traceMessage abre o span da mensagem e retorna a função que o encerra
+func (s *Server[T, M]) traceMessage(ctx context.Context, conn *Conn, c T, msg *Message) (context.Context, func()) {
+	if s.opts.tracer == nil {
+		return ctx, noopSpanEnd
+	}
+	if msg.TraceParent != "" {
+		ctx = traceContext.Extract(ctx, propagation.MapCarrier{"traceparent": msg.TraceParent})
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("message.type", msg.Type),
+		attribute.String("client.id", clientID(conn, c)),
+		attribute.String("client.address", conn.RemoteAddr().String()),
+		attribute.Int64("connection.id", int64(conn.seq)),
+	}
+	if msg.Seq != 0 {
+		attrs = append(attrs, attribute.Int64("message.seq", int64(msg.Seq)))
+	}
+	ctx, span := s.opts.tracer.Start(ctx, "message "+msg.Type,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, func() { span.End() }
+}
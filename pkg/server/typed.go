@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// codecCarrier é implementado por *Message (e por mensagens que o embutem)
+// para o Decode usar o codec do servidor que recebeu a mensagem
+type codecCarrier interface {
+	messageCodec() Codec
+}
+
+// binaryCarrier é implementado por *Message (e por mensagens que o embutem)
+// para o Decode ler payloads binários sem passar pelo codec
+type binaryCarrier interface {
+	IsBinary() bool
+	IsJSON() bool
+	Bytes() []byte
+}
+
+// errNotJSON indica payload binário com ContentType que não é JSON
+var errNotJSON = errors.New("binary payload is not JSON")
+
+func (m *Message) messageCodec() Codec {
+	return m.codec
+}
+
+// Decode decodifica o Data da mensagem em V com o codec do servidor que a
+// recebeu (JSON para mensagens criadas fora do servidor). Com V []byte, o
+// payload de uma mensagem binária é retornado como está; em outro V, payload
+// binário que não é JSON é um erro.
+func Decode[V any](msg MessageInterface) (V, error) {
+	var v V
+	if bm, ok := msg.(binaryCarrier); ok && bm.IsBinary() {
+		if raw, ok := any(&v).(*[]byte); ok {
+			*raw = bm.Bytes()
+			return v, nil
+		}
+		if !bm.IsJSON() {
+			return v, fmt.Errorf("decode %q payload: %w", msg.GetType(), errNotJSON)
+		}
+	}
+	var codec Codec = JSONCodec{}
+	if cc, ok := msg.(codecCarrier); ok && cc.messageCodec() != nil {
+		codec = cc.messageCodec()
+	}
+	if err := codec.Unmarshal(msg.GetData(), &v); err != nil {
+		return v, fmt.Errorf("decode %q payload: %w", msg.GetType(), err)
+	}
+	return v, nil
+}
+
+// NewTypedMessage cria a mensagem com payload serializado em Data. Data fica
+// em JSON; com outro codec o servidor serializa o payload de novo no envio.
+func NewTypedMessage[V any](msgType string, payload V) (*Message, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode %q payload: %w", msgType, err)
+	}
+	return &Message{Type: msgType, Data: data, payload: payload}, nil
+}
+
+// typedForCodec serializa com o codec o payload de uma mensagem do
+// NewTypedMessage. Com JSON, ou para outras mensagens, retorna v sem mudanças.
+func typedForCodec(codec Codec, v any) (any, error) {
+	msg, ok := v.(*Message)
+	if !ok || msg.payload == nil {
+		return v, nil
+	}
+	if _, isJSON := codec.(JSONCodec); isJSON {
+		return v, nil
+	}
+	data, err := codec.Marshal(msg.payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode %q payload: %w", msg.Type, err)
+	}
+	cp := *msg
+	cp.Data, cp.codec = data, codec
+	return &cp, nil
+}
+
+// marshalMessage é o codec.Marshal das mensagens enviadas, com o payload do
+// NewTypedMessage no codec do servidor
+func marshalMessage(codec Codec, v any) ([]byte, error) {
+	v, err := typedForCodec(codec, v)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Marshal(v)
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MessageTypeVersion é usado no handshake de versão, tanto no pedido quanto na resposta
+const MessageTypeVersion = "version"
+
+// VersionRequest é o payload enviado pelo client com a versão do protocolo que ele fala
+type VersionRequest struct {
+	Version int `json:"version"`
+}
+
+// VersionResponse confirma a versão aceita ou explica a recusa
+type VersionResponse struct {
+	Version   int    `json:"version"`
+	Supported []int  `json:"supported,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RegisterProtocolVersion registra o MessageFactory usado pelos clients da
+// versão v. Com alguma versão registrada, a primeira mensagem de cada conexão
+// deve ser "version"; versões desconhecidas são recusadas com
+// CloseCodeUnsupportedVersion. Registre as versões antes do Start.
+func (s *Server[T, M]) RegisterProtocolVersion(v int, factory MessageFactory[M]) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	if s.versions == nil {
+		s.versions = make(map[int]MessageFactory[M])
+	}
+	s.versions[v] = factory
+}
+
+// supportedVersions retorna as versões registradas em ordem crescente
+func (s *Server[T, M]) supportedVersions() []int {
+	s.handlersMu.RLock()
+	defer s.handlersMu.RUnlock()
+	versions := make([]int, 0, len(s.versions))
+	for v := range s.versions {
+		versions = append(versions, v)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// negotiateVersion faz o handshake de versão e guarda a versão aceita na conexão
+func (s *Server[T, M]) negotiateVersion(conn *Conn) error {
+	stream, msg, err := s.readHandshake(conn, MessageTypeVersion)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	var req VersionRequest
+	if err := s.opts.codec.Unmarshal(msg.Data, &req); err != nil {
+		return err
+	}
+	supported := s.supportedVersions()
+	if !slices.Contains(supported, req.Version) {
+		err := fmt.Errorf("unsupported protocol version %d (supported: %s)", req.Version, joinInts(supported))
+		s.writeHandshake(stream, MessageTypeVersion, VersionResponse{Version: req.Version, Supported: supported, Error: err.Error()})
+		return err
+	}
+	conn.protocolVersion = req.Version
+	return s.writeHandshake(stream, MessageTypeVersion, VersionResponse{Version: req.Version})
+}
+
+// messageFactory retorna o MessageFactory da versão negociada pela conexão
+func (s *Server[T, M]) messageFactory(conn *Conn) MessageFactory[M] {
+	s.handlersMu.RLock()
+	factory, ok := s.versions[conn.protocolVersion]
+	s.handlersMu.RUnlock()
+	if !ok || factory == nil {
+		return s.MessageFactory
+	}
+	return factory
+}
+
+// newMessage cria a mensagem com o factory da conexão; só o StrictMessageFactory
+// retorna erro
+func (s *Server[T, M]) newMessage(conn *Conn, base *Message) (M, error) {
+	s.handlersMu.RLock()
+	factory, versioned := s.versions[conn.protocolVersion]
+	s.handlersMu.RUnlock()
+	if (!versioned || factory == nil) && s.StrictMessageFactory != nil {
+		return s.StrictMessageFactory(base)
+	}
+	return s.messageFactory(conn)(base), nil
+}
+
+// ProtocolVersion retorna a versão do protocolo negociada (0 sem handshake de versão)
+func (c *Conn) ProtocolVersion() int {
+	return c.protocolVersion
+}
+
+func joinInts(v []int) string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = fmt.Sprint(n)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+)
+
+// WithWebTransport aceita sessões WebTransport (HTTP/3) no mesmo listener, no
+// caminho informado. As sessões são entregues aos mesmos callbacks como *Conn,
+// permitindo que clients de navegador usem o servidor sem alterações.
+// A conexão é roteada pelo ALPN: "h3" vai para o WebTransport e os protocolos
+// de WithALPN para o protocolo nativo, permitindo compartilhar a mesma porta UDP.
+func WithWebTransport(path string) Option {
+	return func(o *options) {
+		o.webTransportPath = path
+	}
+}
+
+// newWebTransportServer cria o servidor WebTransport que converte sessões em conexões
+func (s *Server[T, M]) newWebTransportServer() *webtransport.Server {
+	mux := http.NewServeMux()
+	wt := &webtransport.Server{H3: http3.Server{Handler: mux}}
+	mux.HandleFunc(s.opts.webTransportPath, func(w http.ResponseWriter, r *http.Request) {
+		sess, err := wt.Upgrade(w, r)
+		if err != nil {
+			s.reportError("webtransport upgrade", nil, false, err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !s.reserveSlot() {
+			sess.CloseWithError(webtransport.SessionErrorCode(CloseCodeServerFull), ErrServerFull.Error())
+			if s.OnServerFull != nil {
+				s.OnServerFull(sess.RemoteAddr())
+			}
+			return
+		}
+		s.wg.Add(1)
+		// O contexto da requisição deriva da conexão QUIC e carrega as métricas dela
+		s.handleConnection(s.newSessionConn(sess, metricsFromContext(r.Context())))
+	})
+	return wt
+}
+
+func (s *Server[T, M]) newSessionConn(sess *webtransport.Session, metrics *connMetrics) *Conn {
+	c := s.newConn(nil)
+	c.session = sess
+	c.metrics = metrics
+	return c
+}
+
+// isWebTransportConn indica se a conexão negociou HTTP/3 e deve ir para o servidor WebTransport
+func isWebTransportConn(conn *quic.Conn) bool {
+	return conn.ConnectionState().TLS.NegotiatedProtocol == http3.NextProtoH3
+}
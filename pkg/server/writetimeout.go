@@ -0,0 +1,49 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrWriteTimeout indica que uma escrita para o client excedeu o prazo de WithWriteTimeout
+var ErrWriteTimeout = errors.New("write timeout")
+
+// WithWriteTimeout limita o tempo de cada escrita em stream para um client (0 =
+// sem limite). Uma conexão travada deixa de segurar o Send ou o broadcast: ao
+// estourar o prazo a escrita retorna ErrWriteTimeout e o client é tratado como
+// lento, conforme WithSlowClientPolicy (descarta a mensagem ou desconecta).
+func WithWriteTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.writeTimeout = d
+	}
+}
+
+// setWriteDeadline arma o prazo de escrita da stream: o menor entre o
+// WithWriteTimeout e deadline, se algum existir. Retorna se o prazo armado é o
+// do WithWriteTimeout, o único que trata o client como lento.
+func (c *Conn) setWriteDeadline(str interface{ SetWriteDeadline(time.Time) error }, deadline time.Time) bool {
+	byTimeout := false
+	if c.writeTimeout > 0 {
+		if t := time.Now().Add(c.writeTimeout); deadline.IsZero() || t.Before(deadline) {
+			deadline, byTimeout = t, true
+		}
+	}
+	if !deadline.IsZero() {
+		str.SetWriteDeadline(deadline)
+	}
+	return byTimeout
+}
+
+// writeTimeoutErr converte o estouro do prazo do WithWriteTimeout em
+// ErrWriteTimeout e aplica a política de client lento
+func (c *Conn) writeTimeoutErr(err error, byTimeout bool) error {
+	if !byTimeout || !errors.Is(err, os.ErrDeadlineExceeded) {
+		return err
+	}
+	if c.slowPolicy == SlowClientDisconnect {
+		c.closeWithReason(ReasonKicked, CloseCodeWriteTimeout, ErrWriteTimeout.Error())
+	}
+	return fmt.Errorf("%w: %w", ErrWriteTimeout, err)
+}